@@ -11,15 +11,21 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/flokiorg/go-flokicoin/chaincfg"
 	. "github.com/flokiorg/grpc-miner/common"
 	"github.com/flokiorg/grpc-miner/mining"
 	"github.com/flokiorg/grpc-miner/mining/algo"
 	"github.com/flokiorg/grpc-miner/mining/algo/common"
+	"github.com/flokiorg/grpc-miner/mining/compress"
 	"github.com/flokiorg/grpc-miner/mining/pb"
+	"github.com/flokiorg/grpc-miner/pool"
 	"github.com/flokiorg/grpc-miner/utils"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -27,12 +33,41 @@ import (
 	"github.com/jessevdk/go-flags"
 )
 
+const (
+	// exitCodeSubmissionLost reports a shutdown that had to abandon a found block,
+	// signal-triggered shutdowns otherwise exit with 128+signal.
+	exitCodeSubmissionLost = 3
+)
+
 const (
 	defaultPoolPort       = 80
 	defaultConfigFilename = "gminer.conf"
+	defaultJournalFile    = "gminer.journal"
+	defaultLedgerFile     = "gminer.ledger"
+	defaultXpubIndexFile  = "gminer.xpub"
 	defaultMaxRetries     = 5
+	defaultMinBackoffSecs = 1.0
 	defaultMaxBackoffSecs = 30.0
 	defaultPoolTimeout    = time.Second * 30
+
+	defaultShutdownTimeout = time.Second * 30
+	defaultLedgerInterval  = time.Minute * 5
+	defaultHeartbeat       = time.Second * 30
+	defaultMaxSilence      = time.Minute * 3
+
+	defaultVerifyTemplates = mining.VerifyStrict
+
+	defaultCompression      = compress.Auto
+	defaultMaxMessageSize   = 32 << 20
+	defaultKeepaliveTime    = time.Minute * 5
+	defaultKeepaliveTimeout = time.Second * 20
+
+	defaultIdleCPUHigh     = 0.30
+	defaultIdleCPULow      = 0.10
+	defaultIdleRunnable    = 0.50
+	defaultIdleInputTime   = time.Minute * 2
+	defaultIdleResumeDelay = time.Second * 30
+	defaultIdleInterval    = time.Second * 5
 )
 
 var (
@@ -86,13 +121,31 @@ func main() {
 		exitWithError(fmt.Sprintf("invalid algo: %s", cfg.Algo), err)
 	}
 
-	// Validate mining addresses or Xpub
+	// Validate mining addresses or Xpub, payout addresses are derived from the xpub locally
+	var (
+		miningAddrs []string
+		payouts     []*pb.Payout
+	)
 	if opt := parser.FindOptionByShortName('d'); !optionDefined(opt) || len(cfg.MiningAddrs) == 0 {
-		if !cfg.TestNet && cfg.Xpub == "" {
-			cfg.Xpub = readXpub()
+		if !cfg.TestNet && cfg.Xpub == "" && cfg.Node == "" {
+			cfg.Xpub = readXpub(mining.ChainParams(cfg.TestNet))
+		}
+		if cfg.Xpub != "" {
+			if _, err := mining.ParseXpub(cfg.Xpub, mining.ChainParams(cfg.TestNet)); err != nil {
+				exitWithError("Invalid xpub", err)
+			}
 		}
 	} else {
 		cfg.Xpub = "" // Ignore Xpub if MiningAddrs is set
+		if miningAddrs, payouts, err = mining.ParsePayouts(cfg.MiningAddrs, mining.ChainParams(cfg.TestNet)); err != nil {
+			exitWithError("Invalid mining addresses", err)
+		}
+	}
+	if cfg.Node != "" && cfg.Xpub != "" {
+		exitWithError("Solo mining (--node) does not support xpubs (-x, --xpub), set a mining address (-d, --miningaddr).", nil)
+	}
+	if cfg.Node != "" && len(cfg.MiningAddrs) == 0 {
+		exitWithError("Solo mining (--node) requires a mining address (-d, --miningaddr).", nil)
 	}
 
 	// Validate Threads
@@ -103,12 +156,29 @@ func main() {
 		log.Warn().Msgf("Threads should not exceed the recommended limit: %d", common.DefaultThreadsMax)
 	}
 
-	// Validate pool endpoint
-	if opt := parser.FindOptionByShortName('p'); !optionDefined(opt) {
-		exitWithError("Pool endpoint (-p, --pool) is required but not provided.", nil)
+	// Validate pool endpoint, or serve a local pool on top of the node when mining solo
+	stopSolo := func() {}
+	if cfg.Node != "" {
+		if stopSolo, err = serveSolo(&cfg, mining.ChainParams(cfg.TestNet)); err != nil {
+			exitWithError("Failed to start solo mining", err)
+		}
+	} else {
+		if opt := parser.FindOptionByShortName('p'); !optionDefined(opt) {
+			exitWithError("Pool endpoint (-p, --pool) is required but not provided.", nil)
+		}
+		if cfg.PoolServer, err = utils.ValidateAndNormalizeURI(cfg.PoolServer, defaultPoolPort); err != nil {
+			exitWithError("Invalid pool endpoint", err)
+		}
+	}
+
+	// Identify the rig to the pool
+	if opt := parser.FindOptionByLongName("worker"); !optionDefined(opt) || cfg.Worker == "" {
+		if cfg.Worker, err = os.Hostname(); err != nil {
+			exitWithError("Worker name (-w, --worker) is required, the hostname is unavailable.", err)
+		}
 	}
-	if cfg.PoolServer, err = utils.ValidateAndNormalizeURI(cfg.PoolServer, defaultPoolPort); err != nil {
-		exitWithError("Invalid pool endpoint", err)
+	if opt := parser.FindOptionByLongName("rig"); !optionDefined(opt) {
+		cfg.RigID = utils.RigID()
 	}
 
 	// No validation needed if slowDownDuration is zero; it disables the slowdown feature.
@@ -118,7 +188,7 @@ func main() {
 
 	var cbs *pb.CoinbaseScript
 	if opt := parser.FindOptionByShortName('s'); optionDefined(opt) {
-		bLeft, cbsText, bRight, err := parseCoinbaseScript(cfg.CoinbaseScript)
+		bLeft, cbsText, bRight, err := parseCoinbaseScript(cfg.CoinbaseScript, cfg.Worker, cfg.RigID)
 		if err != nil {
 			exitWithError("Invalid coinbase script input", err)
 		}
@@ -139,15 +209,117 @@ func main() {
 		cfg.MaxRetries = defaultMaxRetries
 	}
 
+	if opt := parser.FindOptionByLongName("retryMinBackoff"); !optionDefined(opt) {
+		cfg.MinBackoffSeconds = defaultMinBackoffSecs
+	}
+
 	if opt := parser.FindOptionByLongName("retryMaxBackoff"); !optionDefined(opt) {
 		cfg.MaxBackoffSeconds = defaultMaxBackoffSecs
 	}
+	if cfg.MinBackoffSeconds < 0 || cfg.MaxBackoffSeconds < cfg.MinBackoffSeconds {
+		exitWithError(fmt.Sprintf("Invalid retry backoff: min=%.1f max=%.1f. Expected 0 <= min <= max.", cfg.MinBackoffSeconds, cfg.MaxBackoffSeconds), nil)
+	}
 
 	// Validate grpc timeout
 	if opt := parser.FindOptionByLongName("timeout"); !optionDefined(opt) {
 		cfg.PoolTimeout = defaultPoolTimeout
 	}
 
+	if opt := parser.FindOptionByLongName("shutdownTimeout"); !optionDefined(opt) {
+		cfg.ShutdownTimeout = defaultShutdownTimeout
+	}
+
+	if opt := parser.FindOptionByLongName("ledgerInterval"); !optionDefined(opt) {
+		cfg.LedgerInterval = defaultLedgerInterval
+	}
+	if cfg.LedgerInterval < 0 {
+		exitWithError(fmt.Sprintf("Invalid ledgerInterval: %v. It cannot be negative.", cfg.LedgerInterval), nil)
+	}
+
+	// Validate dead stream detection
+	if opt := parser.FindOptionByLongName("heartbeat"); !optionDefined(opt) {
+		cfg.Heartbeat = defaultHeartbeat
+	}
+	if opt := parser.FindOptionByLongName("maxSilence"); !optionDefined(opt) {
+		cfg.MaxSilence = defaultMaxSilence
+	}
+	if cfg.Heartbeat < 0 || cfg.MaxSilence < 0 {
+		exitWithError(fmt.Sprintf("Invalid heartbeat=%v maxSilence=%v. They cannot be negative.", cfg.Heartbeat, cfg.MaxSilence), nil)
+	}
+	if cfg.MaxSilence > 0 && cfg.Heartbeat >= cfg.MaxSilence {
+		log.Warn().Msgf("heartbeat (%v) should be shorter than maxSilence (%v)", cfg.Heartbeat, cfg.MaxSilence)
+	}
+
+	// Validate template verification
+	if opt := parser.FindOptionByLongName("verifyTemplates"); !optionDefined(opt) {
+		cfg.VerifyTemplates = defaultVerifyTemplates
+	}
+	switch cfg.VerifyTemplates {
+	case mining.VerifyStrict, mining.VerifyWarn, mining.VerifyOff:
+	default:
+		exitWithError(fmt.Sprintf("Invalid verifyTemplates: %s. Expected strict, warn or off.", cfg.VerifyTemplates), nil)
+	}
+
+	// Validate connection tuning
+	if opt := parser.FindOptionByLongName("compression"); !optionDefined(opt) {
+		cfg.Compression = defaultCompression
+	}
+	if _, err := compress.Preference(cfg.Compression); err != nil {
+		exitWithError("Invalid compression", err)
+	}
+	if opt := parser.FindOptionByLongName("maxMessageSize"); !optionDefined(opt) {
+		cfg.MaxMessageSize = defaultMaxMessageSize
+	}
+	if opt := parser.FindOptionByLongName("keepaliveTime"); !optionDefined(opt) {
+		cfg.KeepaliveTime = defaultKeepaliveTime
+	}
+	if opt := parser.FindOptionByLongName("keepaliveTimeout"); !optionDefined(opt) {
+		cfg.KeepaliveTimeout = defaultKeepaliveTimeout
+	}
+	if cfg.MaxMessageSize < 0 || cfg.InitialWindowSize < 0 || cfg.KeepaliveTime < 0 || cfg.KeepaliveTimeout < 0 {
+		exitWithError(fmt.Sprintf("Invalid maxMessageSize=%d initialWindowSize=%d keepaliveTime=%v keepaliveTimeout=%v. They cannot be negative.",
+			cfg.MaxMessageSize, cfg.InitialWindowSize, cfg.KeepaliveTime, cfg.KeepaliveTimeout), nil)
+	}
+	if cfg.InitialWindowSize > 0 && cfg.InitialWindowSize < 64<<10 {
+		exitWithError(fmt.Sprintf("Invalid initialWindowSize=%d. It must be at least 65536 bytes.", cfg.InitialWindowSize), nil)
+	}
+
+	// Validate mining schedule
+	if len(cfg.Schedule) > 0 {
+		if _, err := mining.ParseSchedule(cfg.Schedule, time.Local); err != nil {
+			exitWithError("Invalid mining schedule", err)
+		}
+	}
+
+	// Validate idle mode
+	if opt := parser.FindOptionByLongName("idleCpuHigh"); !optionDefined(opt) {
+		cfg.IdleCPUHigh = defaultIdleCPUHigh
+	}
+	if opt := parser.FindOptionByLongName("idleCpuLow"); !optionDefined(opt) {
+		cfg.IdleCPULow = defaultIdleCPULow
+	}
+	if opt := parser.FindOptionByLongName("idleRunnable"); !optionDefined(opt) {
+		cfg.IdleRunnable = defaultIdleRunnable
+	}
+	if opt := parser.FindOptionByLongName("idleInput"); !optionDefined(opt) {
+		cfg.IdleInputTime = defaultIdleInputTime
+	}
+	if opt := parser.FindOptionByLongName("idleResumeDelay"); !optionDefined(opt) {
+		cfg.IdleResumeDelay = defaultIdleResumeDelay
+	}
+	if opt := parser.FindOptionByLongName("idleInterval"); !optionDefined(opt) {
+		cfg.IdleInterval = defaultIdleInterval
+	}
+	if cfg.IdleCPULow < 0 || cfg.IdleCPUHigh > 1 || cfg.IdleCPULow >= cfg.IdleCPUHigh {
+		exitWithError(fmt.Sprintf("Invalid idle thresholds: low=%.2f high=%.2f. Expected 0 <= low < high <= 1.", cfg.IdleCPULow, cfg.IdleCPUHigh), nil)
+	}
+	if cfg.IdleRunnable < 0 {
+		exitWithError(fmt.Sprintf("Invalid idleRunnable: %.2f. It must not be negative.", cfg.IdleRunnable), nil)
+	}
+	if cfg.IdleInterval <= 0 {
+		exitWithError(fmt.Sprintf("Invalid idleInterval: %v. It must be positive.", cfg.IdleInterval), nil)
+	}
+
 	fmt.Println("\nConfiguration:")
 	fmt.Printf("  Algorithm: %s\n", cfg.Algo)
 	fmt.Printf("  Threads: %d\n", cfg.Threads)
@@ -160,27 +332,107 @@ func main() {
 		fmt.Printf("  CoinbaseScript: [%d:%s:%d]\n", cbs.BytesLeft, cbs.Text, cbs.BytesRight)
 	}
 	fmt.Printf("  TestNet: %v\n", cfg.TestNet)
-	fmt.Printf("  Pool: %s\n", cfg.PoolServer)
+	if cfg.Node != "" {
+		fmt.Printf("  Node: %s (solo)\n", cfg.Node)
+	} else {
+		fmt.Printf("  Pool: %s\n", cfg.PoolServer)
+	}
+	fmt.Printf("  Worker: %s (rig %s)\n", cfg.Worker, cfg.RigID)
+	if len(cfg.Schedule) > 0 {
+		fmt.Printf("  Schedule: %s\n", strings.Join(cfg.Schedule, ", "))
+	}
+	if cfg.IdleMode {
+		fmt.Printf("  IdleMode: cpu %.2f/%.2f, runnable %.2f, input %s, resume after %s\n", cfg.IdleCPUHigh, cfg.IdleCPULow, cfg.IdleRunnable, cfg.IdleInputTime, cfg.IdleResumeDelay)
+	}
 	fmt.Print("\n\n")
 
-	logger := utils.CreateFileLogger(filepath.Join(logDir, "gminer.log"))
+	if opt := parser.FindOptionByLongName("journal"); !optionDefined(opt) {
+		cfg.JournalFile = filepath.Join(logDir, defaultJournalFile)
+	}
+	if opt := parser.FindOptionByLongName("ledger"); !optionDefined(opt) {
+		cfg.LedgerFile = filepath.Join(logDir, defaultLedgerFile)
+	}
+	if opt := parser.FindOptionByLongName("xpubIndex"); !optionDefined(opt) {
+		cfg.XpubIndexFile = filepath.Join(logDir, defaultXpubIndexFile)
+	}
+
+	logger, logFile := utils.CreateFileLogger(filepath.Join(logDir, "gminer.log"))
 	request := &pb.CandidateRequest{
-		MiningAddrs:    cfg.MiningAddrs,
+		MiningAddrs:    miningAddrs,
+		Payouts:        payouts,
 		Xpub:           cfg.Xpub,
 		CoinbaseScript: cbs,
+		Worker: &pb.Worker{
+			Name:            cfg.Worker,
+			RigId:           cfg.RigID,
+			SoftwareVersion: utils.Version,
+			Algorithm:       cfg.Algo,
+			Threads:         uint32(cfg.Threads),
+		},
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var received atomic.Value
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-sigs
+		received.Store(sig)
+		logger.Warn().Msgf("received %s, finishing pending submissions (press Ctrl-C again to force)", sig)
+		cancel()
+
+		sig = <-sigs
+		logger.Warn().Msgf("received %s again, exiting immediately", sig)
+		logFile.Sync()
+		os.Exit(exitCode(sig))
+	}()
+
 	miner := mining.NewMiner(&cfg, hashAlgo, request, logger)
 
+	code := 0
 	if cfg.TestNet && cfg.Generate > 0 {
-		miner.Generate(context.Background(), cfg.Generate)
-	} else {
-		miner.Run(context.Background())
+		miner.Generate(ctx, cfg.Generate)
+	} else if err := miner.Run(ctx); err != nil {
+		logger.Error().Err(err).Msg("miner stopped")
+		code = 1
+		if errors.Is(err, mining.ErrSubmissionAbandoned) {
+			code = exitCodeSubmissionLost
+		}
+	}
+
+	if sig, ok := received.Load().(os.Signal); ok && code == 0 {
+		code = exitCode(sig)
+	}
+
+	stopSolo()
+	logFile.Sync()
+	logFile.Close()
+	os.Exit(code)
+}
+
+// serveSolo points the miner to an in-process pool that takes its templates
+// from the node, so solo mining goes through the same client as pool mining.
+// It returns the function stopping the pool once the miner is done.
+func serveSolo(cfg *Config, params *chaincfg.Params) (func(), error) {
+	backend := pool.NewNodeBackend(cfg.Node, cfg.NodeUser, cfg.NodePass, params)
+	addr, stop, err := pool.NewServer(backend).ServeLocal()
+	if err != nil {
+		return nil, err
 	}
+	cfg.PoolServer = addr
+	return stop, nil
+}
 
+func exitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
 }
 
-func readXpub() string {
+func readXpub(params *chaincfg.Params) string {
 	reader := bufio.NewReader(os.Stdin)
 	for {
 		fmt.Print("Enter your xpub key: ")
@@ -196,6 +448,10 @@ func readXpub() string {
 			fmt.Println("xpub key cannot be empty. Please enter a valid xpub.")
 			continue
 		}
+		if _, err := mining.ParseXpub(xpub, params); err != nil {
+			fmt.Printf("Invalid xpub: %v. Please enter a valid xpub.\n", err)
+			continue
+		}
 
 		return xpub
 	}
@@ -215,15 +471,19 @@ func getLogDir(configPath string) (string, error) {
 	return filepath.Dir(exePath), nil
 }
 
-func parseCoinbaseScript(input string) (int, string, int, error) {
-	parts := strings.Split(input, ":")
-	if len(parts) != 3 {
+// parseCoinbaseScript splits <left-bytes>:<custom-text>:<right-bytes>, the text
+// may itself contain colons. The text is a template evaluated for every block,
+// it must fit MaxCoinbaseScriptSize whatever its variables expand to.
+func parseCoinbaseScript(input, worker, rig string) (int, string, int, error) {
+	first, last := strings.Index(input, ":"), strings.LastIndex(input, ":")
+	if first < 0 || first == last {
 		return 0, "", 0, errors.New("invalid format, expected <left-bytes>:<custom-text>:<right-bytes>")
 	}
+	parts := []string{input[:first], input[first+1 : last], input[last+1:]}
 
 	leftBytes, err := utils.ParseIntWithDefault(parts[0], DefaultCBSBoundaryBytesSize)
-	if err != nil {
-		return 0, "", 0, fmt.Errorf("invalid left bytes: %v", err)
+	if err != nil || leftBytes < 0 {
+		return 0, "", 0, fmt.Errorf("invalid left bytes: %q", parts[0])
 	}
 
 	customText := parts[1]
@@ -232,11 +492,15 @@ func parseCoinbaseScript(input string) (int, string, int, error) {
 	// }
 
 	rightBytes, err := utils.ParseIntWithDefault(parts[2], DefaultCBSBoundaryBytesSize)
-	if err != nil {
-		return 0, "", 0, fmt.Errorf("invalid right bytes: %v", err)
+	if err != nil || rightBytes < 0 {
+		return 0, "", 0, fmt.Errorf("invalid right bytes: %q", parts[2])
 	}
 
-	totalLength := leftBytes + len(customText) + rightBytes
+	textLength, err := CoinbaseTextSize(customText, worker, rig)
+	if err != nil {
+		return 0, "", 0, err
+	}
+	totalLength := leftBytes + textLength + rightBytes
 	if totalLength > MaxCoinbaseScriptSize {
 		return 0, "", 0, fmt.Errorf("total byte length %d exceeds maximum allowed %d", totalLength, MaxCoinbaseScriptSize)
 	}
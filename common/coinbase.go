@@ -0,0 +1,128 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package common
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxHeightDigits and dateLayout bound what {height} and {date} expand to.
+	maxHeightDigits = 10
+	dateLayout      = "20060102"
+)
+
+// CoinbaseVars are the values coinbase text variables expand to for a template.
+type CoinbaseVars struct {
+	Worker string
+	Rig    string
+	Height int64
+	Time   time.Time
+}
+
+// ExpandCoinbaseText evaluates the coinbase text for a template. The text may
+// hold the variables {worker}, {rig}, {height} and {date} (UTC, YYYYMMDD), and
+// the escapes \xNN for any byte, \{, \} and \\.
+func ExpandCoinbaseText(text string, vars CoinbaseVars) ([]byte, error) {
+	var out []byte
+	err := scanCoinbaseText(text, func(literal []byte, variable string) {
+		out = append(out, literal...)
+		switch variable {
+		case "worker":
+			out = append(out, vars.Worker...)
+		case "rig":
+			out = append(out, vars.Rig...)
+		case "height":
+			out = strconv.AppendInt(out, vars.Height, 10)
+		case "date":
+			out = vars.Time.UTC().AppendFormat(out, dateLayout)
+		}
+	})
+	return out, err
+}
+
+// PlainCoinbaseText reports whether text holds neither variables nor escapes,
+// so that pools which do not expand it still put it in the coinbase as is.
+func PlainCoinbaseText(text string) bool {
+	return !strings.ContainsAny(text, `\{}`)
+}
+
+// CoinbaseTextSize returns the largest size the text can expand to for any
+// template mined by worker on rig.
+func CoinbaseTextSize(text, worker, rig string) (int, error) {
+	size := 0
+	err := scanCoinbaseText(text, func(literal []byte, variable string) {
+		size += len(literal)
+		switch variable {
+		case "worker":
+			size += len(worker)
+		case "rig":
+			size += len(rig)
+		case "height":
+			size += maxHeightDigits
+		case "date":
+			size += len(dateLayout)
+		}
+	})
+	return size, err
+}
+
+// scanCoinbaseText calls emit with each run of literal bytes, followed by the
+// variable that ends it if any.
+func scanCoinbaseText(text string, emit func(literal []byte, variable string)) error {
+	var literal []byte
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; c {
+		case '\\':
+			if i+1 == len(text) {
+				return fmt.Errorf("dangling escape at the end of %q", text)
+			}
+			i++
+			switch text[i] {
+			case '\\', '{', '}':
+				literal = append(literal, text[i])
+			case 'x':
+				if i+2 >= len(text) {
+					return fmt.Errorf("incomplete hex escape in %q", text)
+				}
+				b, err := hex.DecodeString(text[i+1 : i+3])
+				if err != nil {
+					return fmt.Errorf("invalid hex escape \\x%s in %q", text[i+1:i+3], text)
+				}
+				literal = append(literal, b...)
+				i += 2
+			default:
+				return fmt.Errorf("unknown escape \\%c in %q", text[i], text)
+			}
+
+		case '{':
+			end := strings.IndexByte(text[i:], '}')
+			if end < 0 {
+				return fmt.Errorf("unterminated variable in %q", text)
+			}
+			variable := text[i+1 : i+end]
+			switch variable {
+			case "worker", "rig", "height", "date":
+			default:
+				return fmt.Errorf("unknown variable {%s}, expected {worker}, {rig}, {height} or {date}", variable)
+			}
+			emit(literal, variable)
+			literal = literal[:0]
+			i += end
+
+		case '}':
+			return fmt.Errorf("unexpected } in %q, escape it as \\}", text)
+
+		default:
+			literal = append(literal, c)
+		}
+	}
+	emit(literal, "")
+	return nil
+}
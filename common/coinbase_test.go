@@ -0,0 +1,86 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package common
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpandCoinbaseText(t *testing.T) {
+	vars := CoinbaseVars{
+		Worker: "rig-01",
+		Rig:    "4f2a9c",
+		Height: 123456,
+		Time:   time.Date(2024, 3, 9, 23, 30, 0, 0, time.FixedZone("UTC-2", -2*3600)),
+	}
+
+	tests := []struct {
+		text string
+		want []byte
+		fail string
+	}{
+		{"plain text", []byte("plain text"), ""},
+		{"/{worker}@{rig}/", []byte("/rig-01@4f2a9c/"), ""},
+		{"b{height} {date}", []byte("b123456 20240310"), ""},
+		{`\x00\xffend`, []byte{0x00, 0xff, 'e', 'n', 'd'}, ""},
+		{`\{worker\} \\`, []byte(`{worker} \`), ""},
+		{"{miner}", nil, "unknown variable"},
+		{"{height", nil, "unterminated"},
+		{"height}", nil, "unexpected }"},
+		{`\x4`, nil, "incomplete hex"},
+		{`\xzz`, nil, "invalid hex"},
+		{`\n`, nil, "unknown escape"},
+		{`end\`, nil, "dangling"},
+	}
+
+	for _, test := range tests {
+		got, err := ExpandCoinbaseText(test.text, vars)
+		if test.fail != "" {
+			if err == nil || !strings.Contains(err.Error(), test.fail) {
+				t.Fatalf("%q: expected an error about %q, got=%v", test.text, test.fail, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: %v", test.text, err)
+		}
+		if !bytes.Equal(got, test.want) {
+			t.Fatalf("%q: want=%q got=%q", test.text, test.want, got)
+		}
+	}
+}
+
+func TestCoinbaseTextSize(t *testing.T) {
+	size, err := CoinbaseTextSize(`/{worker}/{height}/{date}\xff`, "rig-01", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 1 + 6 + 1 + 10 + 1 + 8 + 1; size != want {
+		t.Fatalf("want=%d got=%d", want, size)
+	}
+
+	expanded, _ := ExpandCoinbaseText(`/{worker}/{height}/{date}\xff`, CoinbaseVars{Worker: "rig-01", Height: 1 << 31})
+	if len(expanded) > size {
+		t.Fatalf("expansion of %d bytes exceeds the bound of %d", len(expanded), size)
+	}
+}
+
+func TestPlainCoinbaseText(t *testing.T) {
+	for text, plain := range map[string]bool{
+		"":              true,
+		"/gminer/":      true,
+		"/{worker}/":    false,
+		`\xff`:          false,
+		`/a\\b/`:        false,
+		"/height 1234/": true,
+	} {
+		if got := PlainCoinbaseText(text); got != plain {
+			t.Errorf("PlainCoinbaseText(%q) = %v, want %v", text, got, plain)
+		}
+	}
+}
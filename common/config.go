@@ -17,15 +17,42 @@ type Config struct {
 	Threads           uint8         `short:"t" long:"threads" description:"Number of threads to use (default: all available threads)"`
 	MiningAddrs       []string      `short:"d" long:"miningaddr" description:"Specify payment addresses for mining rewards"`
 	Xpub              string        `short:"x" long:"xpub" description:"xpub address (ignored if --miningaddr is set)"`
+	XpubIndexFile     string        `long:"xpubIndex" description:"Path of the file keeping the next xpub derivation index (default: gminer.xpub next to the log file)"`
 	TestNet           bool          `long:"testnet" description:"Use testnet instead of mainnet"`
 	PoolServer        string        `short:"p" long:"pool" description:"Endpoint for the pool server host:port"`
+	Node              string        `long:"node" description:"Mine solo against this flokicoind JSON-RPC endpoint host:port instead of a pool"`
+	NodeUser          string        `long:"nodeuser" description:"Username for the flokicoind JSON-RPC endpoint"`
+	NodePass          string        `long:"nodepass" description:"Password for the flokicoind JSON-RPC endpoint"`
+	Worker            string        `short:"w" long:"worker" description:"Worker name reported to the pool (default: hostname)"`
+	RigID             string        `long:"rig" description:"Rig identifier reported to the pool (default: derived from the machine id)"`
 	PoolTimeout       time.Duration `short:"o" long:"timeout" default:"10s" description:"GRPC dial timeout (e.g., 5s, 1m)"`
+	Heartbeat         time.Duration `long:"heartbeat" description:"Interval between heartbeats sent to the pool (0 disables them)"`
+	MaxSilence        time.Duration `long:"maxSilence" description:"Reconnect when nothing is received from the pool for this long (0 disables it)"`
+	Compression       string        `long:"compression" description:"Message compression to offer the pool (auto, zstd, gzip, none)"`
+	MaxMessageSize    int           `long:"maxMessageSize" description:"Largest gRPC message in bytes sent to or accepted from the pool"`
+	InitialWindowSize int32         `long:"initialWindowSize" description:"Initial HTTP/2 flow control window in bytes (0 uses the gRPC default)"`
+	KeepaliveTime     time.Duration `long:"keepaliveTime" description:"Interval between transport keepalive pings when the connection is idle"`
+	KeepaliveTimeout  time.Duration `long:"keepaliveTimeout" description:"Time to wait for a keepalive ping acknowledgement before closing the connection"`
+	VerifyTemplates   string        `long:"verifyTemplates" description:"Check that templates pay our addresses before mining them (strict, warn, off)"`
 	SlowDownDuration  time.Duration `short:"z" long:"slowDownDuration" description:"Slow down duration in seconds between each new block"`
 	Generate          int           `long:"generate" description:"Number of blocks to generate (testnet only)"`
 	MineOnce          bool          `long:"mineonce" description:"Mine only blocks and exit after one cycle"`
 	CoinbaseScript    string        `short:"s" long:"coinbaseScript" description:"Custom Coinbase script in the format <left-bytes>:<text>:<right-bytes>"`
 	BlockSiesta       time.Duration `long:"blockSiesta" description:"Pause duration between mined blocks"`
 	MaxRetries        int           `long:"retryMaxAttempts" description:"Maximum number of retry attempts before giving up"`
+	MinBackoffSeconds float64       `long:"retryMinBackoff" description:"Minimum backoff time in seconds before retrying"`
 	MaxBackoffSeconds float64       `long:"retryMaxBackoff" description:"Maximum backoff time in seconds before retrying"`
+	JournalFile       string        `long:"journal" description:"Path of the found solutions journal (default: gminer.journal next to the log file)"`
+	LedgerFile        string        `long:"ledger" description:"Path of the ledger of found blocks and their rewards (default: gminer.ledger next to the log file)"`
+	LedgerInterval    time.Duration `long:"ledgerInterval" description:"Interval between checks of where found blocks stand in the chain (0 disables them)"`
+	ShutdownTimeout   time.Duration `long:"shutdownTimeout" description:"Maximum time to wait for pending block submissions on shutdown"`
+	Schedule          []string      `long:"schedule" description:"Mining window in local time such as \"Mon-Fri 22:00-06:00\" (can be repeated)"`
+	IdleMode          bool          `long:"idle" description:"Only mine while the machine is otherwise unused"`
+	IdleCPUHigh       float64       `long:"idleCpuHigh" description:"Pause mining when other processes use more than this fraction of the CPU (0-1)"`
+	IdleCPULow        float64       `long:"idleCpuLow" description:"Resume mining once other processes use less than this fraction of the CPU (0-1)"`
+	IdleRunnable      float64       `long:"idleRunnable" description:"Resume mining once other processes have at most this many runnable tasks per CPU"`
+	IdleInputTime     time.Duration `long:"idleInput" description:"Minimum time without user input before mining, where input idle time is available"`
+	IdleResumeDelay   time.Duration `long:"idleResumeDelay" description:"How long the machine must stay idle before mining resumes"`
+	IdleInterval      time.Duration `long:"idleInterval" description:"Interval between system load samples in idle mode"`
 	Version           bool          `short:"v" description:"Print version"`
 }
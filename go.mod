@@ -5,6 +5,7 @@ go 1.26.5
 require (
 	github.com/flokiorg/go-flokicoin v0.26.0-alpha
 	github.com/jessevdk/go-flags v1.6.1
+	github.com/klauspost/compress v1.17.11
 	github.com/rs/zerolog v1.34.0
 	google.golang.org/grpc v1.79.3
 	google.golang.org/protobuf v1.36.10
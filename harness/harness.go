@@ -0,0 +1,185 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+// Package harness generates test chains by mining real low difficulty blocks
+// with the scrypt CPU miner against the reference pool. Given the same
+// options it always produces the same chain.
+package harness
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/flokiorg/go-flokicoin/chaincfg"
+	"github.com/flokiorg/go-flokicoin/wire"
+	"github.com/flokiorg/grpc-miner/common"
+	"github.com/flokiorg/grpc-miner/mining"
+	. "github.com/flokiorg/grpc-miner/mining/algo/common"
+	"github.com/flokiorg/grpc-miner/mining/algo/cpu"
+	"github.com/flokiorg/grpc-miner/mining/pb"
+	"github.com/flokiorg/grpc-miner/pool"
+	"github.com/flokiorg/grpc-miner/utils"
+)
+
+const defaultTimeout = 10 * time.Second
+
+type Options struct {
+	// Params is the network of the chain, regtest unless set.
+	Params *chaincfg.Params
+
+	// Address is paid the reward of every block.
+	Address string
+
+	// CoinbaseText goes into every coinbase, see common.ExpandCoinbaseText
+	// for the variables it may hold. Worker is what {worker} expands to.
+	CoinbaseText string
+	Worker       string
+
+	// Start is the timestamp of the first generated block, and Spacing the
+	// time between blocks. The first block comes one Spacing after the
+	// genesis block and Spacing is the network's TargetTimePerBlock unless set.
+	Start   time.Time
+	Spacing time.Duration
+
+	// Bits is the target of every block, the network's proof of work limit
+	// unless set. Only regtest's limit is low enough to mine on a CPU.
+	Bits uint32
+}
+
+// Harness is a reference pool on a chain of its own, along with a miner
+// connected to it. Other miners may join through Addr.
+type Harness struct {
+	backend *pool.ChainBackend
+	addr    string
+	stop    func()
+	client  *mining.Client
+	request *pb.CandidateRequest
+}
+
+func New(opts Options) (*Harness, error) {
+	params := opts.Params
+	if params == nil {
+		params = &chaincfg.RegressionNetParams
+	}
+	if opts.Address == "" {
+		return nil, errors.New("harness needs an address to pay blocks to")
+	}
+	start, spacing := opts.Start, opts.Spacing
+	if spacing == 0 {
+		spacing = params.TargetTimePerBlock
+	}
+	if start.IsZero() {
+		start = params.GenesisBlock.Header.Timestamp.Add(spacing)
+	}
+
+	backend := pool.NewChainBackend(params)
+	if opts.Bits != 0 {
+		backend.Bits = opts.Bits
+	}
+	backend.Time = func(height int64) time.Time {
+		return start.Add(time.Duration(height-1) * spacing)
+	}
+
+	addr, stop, err := pool.NewServer(backend).ServeLocal()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &common.Config{PoolServer: addr, PoolTimeout: defaultTimeout}
+	client, err := mining.NewClient(cfg, &pb.HelloRequest{
+		ProtocolVersion: mining.ProtocolVersion,
+		SoftwareVersion: utils.Version,
+		Algorithms:      []string{"scrypt"},
+		Features:        []pb.Feature{pb.Feature_FEATURE_SESSION, pb.Feature_FEATURE_COINBASE_TEXT},
+	})
+	if err != nil {
+		stop()
+		return nil, err
+	}
+
+	h := &Harness{
+		backend: backend,
+		addr:    addr,
+		stop:    stop,
+		client:  client,
+		request: &pb.CandidateRequest{
+			MiningAddrs: []string{opts.Address},
+			// an explicit script without random bytes keeps blocks reproducible
+			CoinbaseScript: &pb.CoinbaseScript{Text: opts.CoinbaseText},
+			Worker:         &pb.Worker{Name: opts.Worker, Algorithm: "scrypt_cpu", Threads: 1},
+		},
+	}
+	// the pool's Generate call mines through the harness
+	backend.Generator = h.Generate
+	return h, nil
+}
+
+// Addr returns the address of the pool.
+func (h *Harness) Addr() string {
+	return h.addr
+}
+
+// Generate mines numBlocks blocks on top of the tip and returns their hashes.
+func (h *Harness) Generate(ctx context.Context, numBlocks int) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	blocks := make(chan *pb.CandidateBlock)
+	go h.client.Listen(ctx, h.request, blocks)
+
+	var (
+		hashes []string
+		mined  int64
+		miner  = cpu.NewSdtScrypt()
+		stats  = NewStats()
+	)
+	for len(hashes) < numBlocks {
+		var block *pb.CandidateBlock
+		select {
+		case block = <-blocks:
+		case <-ctx.Done():
+			return hashes, ctx.Err()
+		}
+		// the template of the block just mined may still be in flight
+		if block.Height <= mined {
+			continue
+		}
+
+		// a single thread scanning from the first nonce finds the same one every time
+		_, nonce, err := miner.Mine(ctx, stats, block, utils.MinMax{Min: START_NONCE, Max: TOTAL_NONCES}, 0)
+		if err != nil {
+			return hashes, fmt.Errorf("failed to mine block %d: %w", block.Height, err)
+		}
+		ack, err := h.client.SubmitNonce(ctx, block, nonce, 0, 0)
+		if err != nil {
+			return hashes, fmt.Errorf("pool refused block %d: %w", block.Height, err)
+		}
+
+		raw, err := hex.DecodeString(ack.Header)
+		if err != nil {
+			return hashes, err
+		}
+		var header wire.BlockHeader
+		if err := header.Deserialize(bytes.NewReader(raw)); err != nil {
+			return hashes, err
+		}
+		hashes = append(hashes, header.BlockHash().String())
+		mined = block.Height
+	}
+	return hashes, nil
+}
+
+// Blocks returns the chain from the genesis block to the tip.
+func (h *Harness) Blocks() []*wire.MsgBlock {
+	return h.backend.Blocks()
+}
+
+func (h *Harness) Close() {
+	h.client.Close()
+	h.stop()
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package harness
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/flokiorg/go-flokicoin/chaincfg"
+	"github.com/flokiorg/go-flokicoin/chainutil"
+	"github.com/flokiorg/grpc-miner/common"
+	"github.com/flokiorg/grpc-miner/mining"
+	"github.com/flokiorg/grpc-miner/mining/pb"
+)
+
+func testOptions(t *testing.T) Options {
+	t.Helper()
+	addr, err := chainutil.NewAddressPubKeyHash(make([]byte, 20), &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Options{
+		Address:      addr.EncodeAddress(),
+		CoinbaseText: "/{worker}/{height}/",
+		Worker:       "harness",
+		Start:        time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		Spacing:      time.Minute,
+	}
+}
+
+func generate(t *testing.T, opts Options, numBlocks int) (*Harness, []string) {
+	t.Helper()
+
+	h, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(h.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	hashes, err := h.Generate(ctx, numBlocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h, hashes
+}
+
+func TestGenerate(t *testing.T) {
+	opts := testOptions(t)
+	h, hashes := generate(t, opts, 3)
+
+	blocks := h.Blocks()
+	if len(blocks) != 4 {
+		t.Fatalf("expected the genesis block and 3 blocks, got %d", len(blocks))
+	}
+	for height, block := range blocks[1:] {
+		height++
+		if block.BlockHash().String() != hashes[height-1] {
+			t.Fatalf("block %d: hash %s, generated %s", height, block.BlockHash(), hashes[height-1])
+		}
+		if block.Header.PrevBlock != blocks[height-1].BlockHash() {
+			t.Fatalf("block %d does not extend block %d", height, height-1)
+		}
+		if want := opts.Start.Add(time.Duration(height-1) * time.Minute); !block.Header.Timestamp.Equal(want) {
+			t.Fatalf("block %d: timestamp %s, want %s", height, block.Header.Timestamp, want)
+		}
+		text, _ := common.ExpandCoinbaseText(opts.CoinbaseText, common.CoinbaseVars{Worker: "harness", Height: int64(height)})
+		if !bytes.Contains(block.Transactions[0].TxIn[0].SignatureScript, text) {
+			t.Fatalf("block %d: coinbase misses %q", height, text)
+		}
+	}
+
+	// the same options give the same chain
+	if _, again := generate(t, opts, 3); !slices.Equal(hashes, again) {
+		t.Fatalf("chains differ:\n%v\n%v", hashes, again)
+	}
+	opts.CoinbaseText = "/other/"
+	if _, other := generate(t, opts, 1); other[0] == hashes[0] {
+		t.Fatal("another coinbase text gave the same block")
+	}
+}
+
+func TestPoolGenerate(t *testing.T) {
+	h, err := New(testOptions(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	// gminer --generate reaches the harness through the pool
+	client, err := mining.NewClient(&common.Config{PoolServer: h.Addr(), PoolTimeout: 5 * time.Second}, &pb.HelloRequest{ProtocolVersion: mining.ProtocolVersion})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	hashes, err := client.Generate(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blocks := h.Blocks(); len(hashes) != 2 || blocks[2].BlockHash().String() != hashes[1] {
+		t.Fatalf("unexpected blocks %v", hashes)
+	}
+}
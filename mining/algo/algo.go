@@ -26,6 +26,12 @@ const (
 	SCRYPT
 )
 
+// PowAlgorithm returns the proof of work computed by an algo option, e.g. scrypt for scrypt_cpu.
+func PowAlgorithm(input string) string {
+	name, _, _ := strings.Cut(strings.ToLower(input), "_")
+	return name
+}
+
 func Parse(input string) (MinerAlgo, error) {
 	switch strings.ToLower(input) {
 
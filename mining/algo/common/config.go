@@ -23,4 +23,7 @@ const (
 
 	START_NONCE uint32 = 0 // 170000000 // 1_550_000_000
 
+	// NONCE_CHUNK is how many nonces a thread mines before checking whether a
+	// queued job should take over.
+	NONCE_CHUNK uint32 = 1 << 20
 )
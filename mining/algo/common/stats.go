@@ -19,6 +19,10 @@ type Stats struct {
 	Iterations  atomic.Uint64
 	TotalHashes atomic.Uint64
 
+	// submission outcomes over the miner lifetime, not cleared by Reset
+	Rejected atomic.Uint64
+	Stale    atomic.Uint64
+
 	zeros     map[uint8]int
 	zerosLock sync.Mutex
 
@@ -6,56 +6,344 @@ package mining
 
 import (
 	"context"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"math"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/flokiorg/grpc-miner/common"
 	"github.com/flokiorg/grpc-miner/mining/pb"
+	"github.com/flokiorg/grpc-miner/utils"
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	ProtocolVersion    uint32 = 2
+	MinProtocolVersion uint32 = 1
+)
+
+const (
+	// transport keepalive, kept at the default minimum gRPC servers enforce so
+	// pools do not drop the connection for pinging too often
+	defaultKeepaliveTime    = 5 * time.Minute
+	defaultKeepaliveTimeout = 20 * time.Second
+
+	defaultMinBackoff = time.Second
+	defaultMaxBackoff = 30 * time.Second
+
+	// maxStatusHashes is how many blocks pools answer about in one BlockStatus call
+	maxStatusHashes = 256
+)
+
+var (
+	ErrStaleSolution          = errors.New("solution is stale, the chain tip moved past its template")
+	ErrBlockStatusUnsupported = errors.New("the pool does not report block status")
+	errStreamSilent           = errors.New("no template or heartbeat received from the pool in time")
+	errRenewed                = errors.New("candidate request renewed")
+
+	// errSessionDown is transient, a solution given up on is kept in the journal
+	errSessionDown = status.Error(codes.Unavailable, "pool session did not resume in time")
 )
 
 type Client struct {
+	// HeartbeatInterval is how often a Session is pinged and MaxSilence how
+	// long a stream may stay silent before it is considered dead. Both must be
+	// set before Listen, zero disables them.
+	HeartbeatInterval time.Duration
+	MaxSilence        time.Duration
+
+	// MinBackoff and MaxBackoff bound the delay between reconnections, and
+	// MinBackoff the delay between submission attempts.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	clock      utils.Clock
+
+	events       *Bus
+	health       atomic.Int32
+	reconnecting atomic.Bool
+
+	hello       *pb.HelloRequest
+	dialTimeout time.Duration
+	dialOptions []grpc.DialOption
+
+	// the pool may redirect the client, which replaces the whole link
+	linkMu     sync.RWMutex
+	address    string
 	conn       *grpc.ClientConn
 	stream     pb.CandidateStreamClient
-	retryChan  chan struct{}
-	retryMutex sync.Mutex
+	protocol   uint32
+	features   map[pb.Feature]bool
+	compressor string
+
+	sessionMu   sync.Mutex
+	session     *session
+	sessionUp   chan struct{}
+	resumeToken string
+	nextID      atomic.Uint64
+
+	// Listen reopens its stream when the request is renewed
+	requestMu sync.Mutex
+	request   *pb.CandidateRequest
+	restart   context.CancelCauseFunc
+
+	tipMu      sync.Mutex
+	tip        *pb.CandidateBlock
+	tipChanged chan struct{}
 }
 
-// NewClient initializes a new gRPC client
-func NewClient(poolserver string, dialTimeout time.Duration) (*Client, error) {
+// NewClient initializes a new gRPC client for cfg.PoolServer and negotiates the protocol with the pool
+func NewClient(cfg *common.Config, hello *pb.HelloRequest) (*Client, error) {
+	c := &Client{
+		HeartbeatInterval: cfg.Heartbeat,
+		MaxSilence:        cfg.MaxSilence,
+		MinBackoff:        defaultMinBackoff,
+		MaxBackoff:        defaultMaxBackoff,
+		clock:             utils.RealClock,
+		hello:             hello,
+		dialTimeout:       cfg.PoolTimeout,
+		dialOptions:       dialOptions(cfg),
+		sessionUp:         make(chan struct{}),
+		tipChanged:        make(chan struct{}),
+	}
+	if cfg.MinBackoffSeconds > 0 {
+		c.MinBackoff = seconds(cfg.MinBackoffSeconds)
+	}
+	if cfg.MaxBackoffSeconds > 0 {
+		c.MaxBackoff = seconds(cfg.MaxBackoffSeconds)
+	}
 
-	conn, err := grpc.NewClient(poolserver, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err := c.connect(cfg.PoolServer); err != nil {
+		return nil, err
+	}
+
+	log.Info().Msg("client initialized successfully")
+	return c, nil
+}
+
+// dialOptions tunes the transport from cfg, leaving gRPC defaults for unset values.
+func dialOptions(cfg *common.Config) []grpc.DialOption {
+	params := keepalive.ClientParameters{
+		Time:    defaultKeepaliveTime,
+		Timeout: defaultKeepaliveTimeout,
+	}
+	if cfg.KeepaliveTime > 0 {
+		params.Time = cfg.KeepaliveTime
+	}
+	if cfg.KeepaliveTimeout > 0 {
+		params.Timeout = cfg.KeepaliveTimeout
+	}
+
+	options := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithKeepaliveParams(params),
+	}
+	if cfg.MaxMessageSize > 0 {
+		options = append(options, grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(cfg.MaxMessageSize),
+			grpc.MaxCallSendMsgSize(cfg.MaxMessageSize),
+		))
+	}
+	if cfg.InitialWindowSize > 0 {
+		options = append(options,
+			grpc.WithInitialWindowSize(cfg.InitialWindowSize),
+			grpc.WithInitialConnWindowSize(cfg.InitialWindowSize),
+		)
+	}
+	return options
+}
+
+// connect dials poolserver, checks its health and negotiates the protocol,
+// then replaces the current link with the new one.
+func (c *Client) connect(poolserver string) error {
+
+	conn, err := grpc.NewClient(poolserver, c.dialOptions...)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to connect to gRPC server")
-		return nil, err
+		return err
 	}
 
 	client := pb.NewHealthClient(conn)
 
 	// Health check
-	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), c.dialTimeout)
 	defer cancel()
 
-	_, err = client.Check(ctx, &pb.HealthCheckRequest{})
+	health, err := client.Check(ctx, &pb.HealthCheckRequest{})
 	if err != nil {
 		conn.Close()
 		log.Error().Err(err).Msg("Health check failed, closing connection")
-		return nil, fmt.Errorf("health check failed: %v", err)
+		return fmt.Errorf("health check failed: %v", err)
 	}
 
-	log.Info().Msg("client initialized successfully")
-	return &Client{
-		conn:   conn,
-		stream: pb.NewCandidateStreamClient(conn),
-	}, nil
+	stream := pb.NewCandidateStreamClient(conn)
+	link, err := handshake(ctx, stream, c.hello)
+	if err != nil {
+		conn.Close()
+		log.Error().Err(err).Msg("Handshake failed, closing connection")
+		return err
+	}
+
+	c.linkMu.Lock()
+	previous := c.conn
+	c.address, c.conn, c.stream = poolserver, conn, stream
+	c.protocol, c.features, c.compressor = link.protocol, link.features, link.compressor
+	c.linkMu.Unlock()
+
+	if previous != nil {
+		previous.Close()
+	}
+	c.setHealth(health.Status, "", time.Time{})
+	return nil
+}
+
+// negotiation is what the handshake settled on.
+type negotiation struct {
+	protocol   uint32
+	features   map[pb.Feature]bool
+	compressor string
 }
 
-// Listen continuously listens for candidate blocks and synchronizes retries
+// handshake agrees on a protocol version and the features both sides support.
+// Pools predating the handshake are served with protocol v1 and no extensions.
+func handshake(ctx context.Context, stream pb.CandidateStreamClient, hello *pb.HelloRequest) (*negotiation, error) {
+	link := &negotiation{features: make(map[pb.Feature]bool)}
+
+	resp, err := stream.Hello(ctx, hello)
+	if status.Code(err) == codes.Unimplemented {
+		log.Warn().Msg("Pool does not support the handshake, falling back to protocol v1 without extensions")
+		link.protocol = 1
+		return link, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("handshake failed: %w", err)
+	}
+
+	if resp.MinProtocolVersion > hello.ProtocolVersion {
+		return nil, fmt.Errorf("pool requires protocol v%d or newer, this miner speaks v%d: please upgrade gminer", resp.MinProtocolVersion, hello.ProtocolVersion)
+	}
+	if resp.ProtocolVersion < MinProtocolVersion {
+		return nil, fmt.Errorf("pool speaks protocol v%d, older than the minimum v%d supported by this miner", resp.ProtocolVersion, MinProtocolVersion)
+	}
+	for _, algorithm := range hello.Algorithms {
+		if len(resp.Algorithms) > 0 && !slices.Contains(resp.Algorithms, algorithm) {
+			return nil, fmt.Errorf("pool does not accept %s work, it supports %v", algorithm, resp.Algorithms)
+		}
+	}
+
+	link.protocol = min(resp.ProtocolVersion, hello.ProtocolVersion)
+	for _, f := range resp.Features {
+		if slices.Contains(hello.Features, f) {
+			link.features[f] = true
+		}
+	}
+	for _, f := range hello.Features {
+		if !link.features[f] {
+			log.Warn().Str("feature", f.String()).Msg("Pool does not support feature, continuing without it")
+		}
+	}
+	if link.features[pb.Feature_FEATURE_COMPRESSION] && slices.Contains(hello.Compressors, resp.Compressor) {
+		link.compressor = resp.Compressor
+	}
+
+	log.Info().
+		Uint32("protocol", link.protocol).
+		Str("pool", resp.SoftwareVersion).
+		Int("features", len(link.features)).
+		Str("compressor", link.compressor).
+		Msg("Handshake completed")
+	return link, nil
+}
+
+// Supports reports whether a feature was negotiated with the pool.
+func (c *Client) Supports(feature pb.Feature) bool {
+	c.linkMu.RLock()
+	defer c.linkMu.RUnlock()
+	return c.features[feature]
+}
+
+// rpc returns the stub of the current link along with the call options
+// negotiated with the pool.
+func (c *Client) rpc() (pb.CandidateStreamClient, []grpc.CallOption) {
+	c.linkMu.RLock()
+	defer c.linkMu.RUnlock()
+	if c.compressor != "" {
+		return c.stream, []grpc.CallOption{grpc.UseCompressor(c.compressor)}
+	}
+	return c.stream, nil
+}
+
+// Compressor returns the message compressor agreed with the pool, empty when
+// messages are sent uncompressed.
+func (c *Client) Compressor() string {
+	c.linkMu.RLock()
+	defer c.linkMu.RUnlock()
+	return c.compressor
+}
+
+// Address returns the pool the client is currently connected to.
+func (c *Client) Address() string {
+	c.linkMu.RLock()
+	defer c.linkMu.RUnlock()
+	return c.address
+}
+
+// setTip records the latest template received from the pool and wakes up
+// submissions waiting to retry.
+func (c *Client) setTip(block *pb.CandidateBlock) {
+	c.tipMu.Lock()
+	defer c.tipMu.Unlock()
+
+	c.tip = block
+	close(c.tipChanged)
+	c.tipChanged = make(chan struct{})
+}
+
+// staleness returns whether block was superseded by the latest known tip,
+// along with a channel closed on the next tip change.
+func (c *Client) staleness(block *pb.CandidateBlock) (bool, <-chan struct{}) {
+	c.tipMu.Lock()
+	defer c.tipMu.Unlock()
+	return supersedes(c.tip, block), c.tipChanged
+}
+
+// supersedes reports whether tip builds on a different chain state than block:
+// a higher height or a competing parent at the same height. Refreshed templates
+// on the same parent leave earlier solutions valid.
+func supersedes(tip, block *pb.CandidateBlock) bool {
+	if tip == nil || block == nil {
+		return false
+	}
+	if tip.Height != block.Height {
+		return tip.Height > block.Height
+	}
+	return prevBlockHash(tip) != prevBlockHash(block)
+}
+
+func prevBlockHash(block *pb.CandidateBlock) string {
+	// version (4 bytes) followed by the previous block hash (32 bytes)
+	if len(block.Header) < 72 {
+		return ""
+	}
+	return block.Header[8:72]
+}
+
+// Listen continuously listens for candidate blocks, reconnecting whenever the
+// stream breaks. It runs a Session when the pool supports it and falls back to
+// the Open stream otherwise.
 func (c *Client) Listen(ctx context.Context, request *pb.CandidateRequest, blocks chan<- *pb.CandidateBlock) {
-	var attempt int
+	backoff := utils.NewBackoff(c.MinBackoff, c.MaxBackoff, c.clock)
+
+	c.requestMu.Lock()
+	c.request = request
+	c.requestMu.Unlock()
 
 	for {
 		select {
@@ -65,65 +353,263 @@ func (c *Client) Listen(ctx context.Context, request *pb.CandidateRequest, block
 		default:
 		}
 
-		log.Info().Int("attempt", attempt).Msg("Opening stream to listen for candidate blocks...")
+		log.Info().Int("attempt", backoff.Attempt()).Msg("Opening stream to listen for candidate blocks...")
+
+		streamCtx, restart := context.WithCancelCause(ctx)
+		c.requestMu.Lock()
+		request, c.restart = c.request, restart
+		c.requestMu.Unlock()
+
+		var (
+			opened bool
+			err    error
+		)
+		if c.Supports(pb.Feature_FEATURE_SESSION) {
+			opened, err = c.runSession(streamCtx, request, blocks)
+		} else {
+			opened, err = c.listenOpen(streamCtx, request, blocks)
+		}
+		restart(nil)
 
-		stream, err := c.stream.Open(ctx, request)
-		if err != nil {
-			log.Error().Err(err).Msg("Stream open failed, retrying...")
+		if ctx.Err() != nil {
+			log.Info().Msg("Stopping Listen due to context cancellation")
+			return
+		}
 
-			// Notify `SubmitNonce` that connection is unstable
-			select {
-			case c.retryChan <- struct{}{}:
-			default:
-			}
+		if errors.Is(context.Cause(streamCtx), errRenewed) {
+			log.Info().Msg("Candidate request renewed, reopening stream")
+			backoff.Reset()
+			continue
+		}
+
+		if opened {
+			backoff.Reset() //  Reset retry counter once a stream was established
+		}
 
-			// Exponential backoff before retrying
-			attempt++
-			backoff := time.Duration(math.Min(30, math.Pow(2, float64(attempt)))) * time.Second
-			log.Warn().Dur("retry_after", backoff).Msg("Retrying stream open...")
-			time.Sleep(backoff)
+		var r *redirect
+		if errors.As(err, &r) {
+			if err = c.follow(ctx, r); err == nil {
+				continue
+			}
+		}
+		c.reconnecting.Store(true)
+		if err != nil {
+			c.setHealth(pb.HealthStatus_NOT_SERVING, err.Error(), time.Time{})
+		}
 
-			continue // retry opening stream
+		// Exponential backoff before retrying
+		delay := backoff.Next()
+		log.Warn().Err(err).Dur("retry_after", delay).Msg("Stream error detected, retrying...")
+		if backoff.Sleep(ctx, delay, nil) != nil {
+			log.Info().Msg("Stopping Listen due to context cancellation")
+			return
 		}
+	}
+}
+
+// Renew replaces the request Listen asks templates for so that the pool builds
+// the next templates from it. A live session takes it in place, keeping the
+// solutions waiting for their ack; other streams are reopened.
+func (c *Client) Renew(request *pb.CandidateRequest) {
+	c.requestMu.Lock()
+	defer c.requestMu.Unlock()
 
-		log.Info().Msg("Listening for candidate blocks...")
-		attempt = 0 //  Reset retry counter on success
+	c.request = request
+
+	c.sessionMu.Lock()
+	s := c.session
+	c.sessionMu.Unlock()
+	if s != nil && s.send(&pb.MinerMessage{Payload: &pb.MinerMessage_Request{Request: request}}) == nil {
+		return
+	}
+
+	if c.restart != nil {
+		c.restart(errRenewed)
+	}
+}
+
+// follow moves the client to the pool server it was redirected to. The
+// current link is kept if the new server cannot be reached.
+func (c *Client) follow(ctx context.Context, r *redirect) error {
+	log.Warn().Str("address", r.address).Dur("delay", r.delay).Msg("Pool asked to reconnect elsewhere")
+
+	select {
+	case <-c.clock.After(r.delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	from := c.Address()
+	if err := c.connect(r.address); err != nil {
+		return fmt.Errorf("redirect to %s failed: %w", r.address, err)
+	}
+
+	// sessions cannot be resumed across servers
+	c.sessionMu.Lock()
+	c.resumeToken = ""
+	c.sessionMu.Unlock()
+
+	log.Info().Str("address", r.address).Msg("Reconnected to redirected pool")
+	c.events.Publish(PoolSwitched{Time: c.clock.Now(), From: from, To: r.address})
+	return nil
+}
+
+// SetEvents makes the client publish its events to events, it must be called
+// before Listen.
+func (c *Client) SetEvents(events *Bus) {
+	c.events = events
+}
+
+// reconnected reports a stream opened after the previous one broke.
+func (c *Client) reconnected(resumed bool) {
+	if c.reconnecting.Swap(false) {
+		c.events.Publish(StreamReconnected{Time: c.clock.Now(), Pool: c.Address(), Resumed: resumed})
+	}
+}
+
+// setHealth publishes the status of the pool when it changed.
+func (c *Client) setHealth(status pb.HealthStatus, message string, until time.Time) {
+	if pb.HealthStatus(c.health.Swap(int32(status))) == status {
+		return
+	}
+	c.events.Publish(HealthChanged{Time: c.clock.Now(), Pool: c.Address(), Status: status, Message: message, Until: until})
+}
+
+// liveness tracks when a stream last received a message. Time spent waiting
+// for the miner to take a template does not count as silence.
+type liveness struct {
+	seen atomic.Int64
+	busy atomic.Bool
+}
+
+func (l *liveness) touch() {
+	l.seen.Store(time.Now().UnixNano())
+}
+
+func (l *liveness) silence() time.Duration {
+	if l.busy.Load() {
+		return 0
+	}
+	return time.Since(time.Unix(0, l.seen.Load()))
+}
+
+// watchdog cancels ctx once nothing was received for MaxSilence, pinging the
+// pool every HeartbeatInterval meanwhile when ping is set.
+func (c *Client) watchdog(ctx context.Context, cancel context.CancelCauseFunc, ping func() error) *liveness {
+	live := &liveness{}
+	live.touch()
+
+	if c.MaxSilence <= 0 {
+		return live
+	}
+
+	interval := c.HeartbeatInterval
+	if ping == nil || interval <= 0 || interval > c.MaxSilence {
+		interval = c.MaxSilence / 2
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
 
-	loop:
 		for {
 			select {
+			case <-ticker.C:
+				silence := live.silence()
+				if silence > c.MaxSilence {
+					log.Warn().Dur("silence", silence).Msg("Pool stream went silent, reconnecting")
+					cancel(errStreamSilent)
+					return
+				}
+				if ping != nil && c.HeartbeatInterval > 0 {
+					if err := ping(); err != nil {
+						log.Debug().Err(err).Msg("Failed to send heartbeat")
+					}
+				}
+
 			case <-ctx.Done():
-				log.Info().Msg("Stopping Listen due to context cancellation")
 				return
-			default:
-				input, err := stream.Recv()
-				if err != nil {
-					log.Warn().Err(err).Msg("Stream error detected, retrying...")
-
-					// Notify `SubmitNonce` to wait for reconnection
-					select {
-					case c.retryChan <- struct{}{}:
-					default:
-					}
+			}
+		}
+	}()
+
+	return live
+}
 
-					attempt++
-					backoff := time.Duration(math.Min(30, math.Pow(2, float64(attempt)))) * time.Second
-					log.Warn().Dur("retry_after", backoff).Msg("Retrying stream open...")
-					time.Sleep(backoff)
+// listenOpen receives candidate blocks from the protocol v1 Open stream until
+// it breaks. Without heartbeats on this stream, only templates prove it alive.
+func (c *Client) listenOpen(ctx context.Context, request *pb.CandidateRequest, blocks chan<- *pb.CandidateBlock) (bool, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
 
-					break loop //  Restart connection loop
-				}
+	rpc, opts := c.rpc()
+	stream, err := rpc.Open(ctx, request, opts...)
+	if err != nil {
+		return false, fmt.Errorf("stream open failed: %w", err)
+	}
+
+	log.Info().Msg("Listening for candidate blocks...")
+	c.reconnected(false)
+	live := c.watchdog(ctx, cancel, nil)
 
-				log.Info().Str("block", fmt.Sprintf("%v", input.Height)).Msg("Received candidate block")
-				blocks <- input
+	for {
+		input, err := stream.Recv()
+		if err != nil {
+			if cause := context.Cause(ctx); errors.Is(cause, errStreamSilent) {
+				return true, cause
 			}
+			return true, err
+		}
+		live.touch()
+
+		if !c.deliverBlock(ctx, input, blocks, live) {
+			return true, ctx.Err()
 		}
 	}
 }
 
-// SubmitNonce waits if `Listen` is retrying, then submits the nonce
+func (c *Client) deliverBlock(ctx context.Context, block *pb.CandidateBlock, blocks chan<- *pb.CandidateBlock, live *liveness) bool {
+	log.Info().Str("block", fmt.Sprintf("%v", block.Height)).Msg("Received candidate block")
+	c.setTip(block)
+	// a pool handing out work is serving, whatever it reported before
+	c.setHealth(pb.HealthStatus_SERVING, "", time.Time{})
+
+	live.busy.Store(true)
+	defer func() {
+		live.touch()
+		live.busy.Store(false)
+	}()
+
+	select {
+	case blocks <- block:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// sessionWait is how long a submission waits for the session to resume: the
+// pool timeout, but never longer than the submission's own backoff.
+func (c *Client) sessionWait(maxBackoff time.Duration) time.Duration {
+	if maxBackoff <= 0 {
+		maxBackoff = c.MaxBackoff
+	}
+	if c.dialTimeout > 0 && (maxBackoff <= 0 || c.dialTimeout < maxBackoff) {
+		return c.dialTimeout
+	}
+	return maxBackoff
+}
+
+// submit sends a single solution. Jobs the pool assigned an id to are
+// referenced compactly, the whole template is echoed back otherwise.
+// SubmitNonce submits the nonce, retrying until the pool answers, the
+// solution becomes stale or maxRetries is exhausted
 func (c *Client) SubmitNonce(ctx context.Context, block *pb.CandidateBlock, nonce uint32, maxRetries int, maxBackoffSeconds float64) (*pb.AckBlockSubmited, error) {
-	var attempt int
+	var (
+		attempt int
+		sent    bool
+	)
+	backoff := utils.NewBackoff(c.MinBackoff, seconds(maxBackoffSeconds), c.clock)
 
 	log.Info().
 		Str("block", fmt.Sprintf("%v", block.Height)).
@@ -136,25 +622,55 @@ func (c *Client) SubmitNonce(ctx context.Context, block *pb.CandidateBlock, nonc
 			log.Warn().Msg("block submission halted due to context cancellation")
 			return nil, ctx.Err()
 
-		case <-c.retryChan: // Wait if Listen is retrying
-			log.Warn().Msg("Detected connection retry, delaying nonce submission...")
-			time.Sleep(2 * time.Second) // Short delay before checking again
-			continue
-
 		default:
 		}
 
-		// Prevent multiple retry attempts
-		c.retryMutex.Lock()
-		resp, err := c.stream.SubmitValidBlock(ctx, &pb.ValidBlock{Template: block, Nonce: int64(nonce)})
-		c.retryMutex.Unlock()
+		stale, tipChanged := c.staleness(block)
+		if stale {
+			log.Warn().
+				Str("block", fmt.Sprintf("%v", block.Height)).
+				Uint32("nonce", nonce).
+				Msg("Chain tip moved, dropping stale submission")
+			return nil, ErrStaleSolution
+		}
 
-		if err == nil {
-			log.Info().
+		var err error
+		if up := c.sessionDown(); up != nil {
+			// solutions found while the session is down wait for it to resume,
+			// each wait up to the pool timeout or the backoff counting as a
+			// failed attempt
+			log.Warn().
 				Str("block", fmt.Sprintf("%v", block.Height)).
 				Uint32("nonce", nonce).
-				Msg("block submitted successfully")
-			return resp, nil
+				Msg("Waiting for the pool session to resume before submitting...")
+			select {
+			case <-up:
+				continue
+			case <-tipChanged:
+				continue
+			case <-ctx.Done():
+				continue
+			case <-c.clock.After(c.sessionWait(seconds(maxBackoffSeconds))):
+				err = errSessionDown
+			}
+		} else {
+			var resp *pb.AckBlockSubmited
+			if resp, err = c.submit(ctx, block, nonce); err == nil {
+				log.Info().
+					Str("block", fmt.Sprintf("%v", block.Height)).
+					Uint32("nonce", nonce).
+					Msg("block submitted successfully")
+				return resp, nil
+			}
+			if sent && status.Code(err) == codes.AlreadyExists {
+				// an earlier attempt reached the pool, only its answer was lost
+				log.Info().
+					Str("block", fmt.Sprintf("%v", block.Height)).
+					Uint32("nonce", nonce).
+					Msg("block already received by the pool from an earlier attempt")
+				return acceptedAck(block, nonce)
+			}
+			sent = true
 		}
 
 		// Log error and retry if applicable
@@ -166,27 +682,71 @@ func (c *Client) SubmitNonce(ctx context.Context, block *pb.CandidateBlock, nonc
 				Int("attempts", attempt).
 				Err(err).
 				Msg("Failed to submit nonce after multiple attempts")
-			return nil, fmt.Errorf("block submission failed after %d attempts: %v", attempt, err)
+			return nil, fmt.Errorf("block submission failed after %d attempts: %w", attempt, err)
 		}
 
 		// Calculate backoff time
-		backoff := time.Duration(math.Min(maxBackoffSeconds, math.Pow(2, float64(attempt)))) * time.Second
+		delay := backoff.Next()
 		log.Warn().
 			Str("block", fmt.Sprintf("%v", block.Height)).
 			Uint32("nonce", nonce).
 			Int("attempts", attempt).
-			Dur("retry_after", backoff).
+			Dur("retry_after", delay).
 			Err(err).
 			Msg("Retrying block submission...")
 
-		time.Sleep(backoff) // Wait before retrying
+		// Wait before retrying, unless the submission becomes stale meanwhile
+		backoff.Sleep(ctx, delay, tipChanged)
+	}
+}
+
+func (c *Client) submit(ctx context.Context, block *pb.CandidateBlock, nonce uint32) (*pb.AckBlockSubmited, error) {
+	session := c.Supports(pb.Feature_FEATURE_SESSION)
+
+	if block.JobId != "" {
+		compact := &pb.CompactSolution{JobId: block.JobId, Nonce: nonce}
+		if session {
+			return c.submitOverSession(ctx, &pb.Solution{Payload: &pb.Solution_Compact{Compact: compact}})
+		}
+		rpc, opts := c.rpc()
+		return rpc.SubmitCompact(ctx, compact, opts...)
+	}
+
+	valid := &pb.ValidBlock{Template: block, Nonce: int64(nonce)}
+	if session {
+		return c.submitOverSession(ctx, &pb.Solution{Payload: &pb.Solution_Block{Block: valid}})
+	}
+	rpc, opts := c.rpc()
+	return rpc.SubmitValidBlock(ctx, valid, opts...)
+}
+
+// isTransientError reports whether a submission failed for lack of an answer
+// from the pool rather than because the pool rejected it.
+// acceptedAck is the ack of a solution the pool reports it already has.
+func acceptedAck(block *pb.CandidateBlock, nonce uint32) (*pb.AckBlockSubmited, error) {
+	header, err := solvedHeader(block, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.AckBlockSubmited{Header: hex.EncodeToString(header)}, nil
+}
+
+func isTransientError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled, codes.ResourceExhausted, codes.Aborted:
+		return true
 	}
+	return false
 }
 
 func (c *Client) Generate(ctx context.Context, blocks int) ([]string, error) {
-	res, err := c.stream.Generate(ctx, &pb.GenerateRequest{
+	rpc, opts := c.rpc()
+	res, err := rpc.Generate(ctx, &pb.GenerateRequest{
 		NumBlocks: int32(blocks),
-	})
+	}, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -194,6 +754,40 @@ func (c *Client) Generate(ctx context.Context, blocks int) ([]string, error) {
 	return res.Blocks, nil
 }
 
+// Health checks the pool is serving.
+func (c *Client) Health(ctx context.Context) (pb.HealthStatus, error) {
+	c.linkMu.RLock()
+	conn := c.conn
+	c.linkMu.RUnlock()
+
+	resp, err := pb.NewHealthClient(conn).Check(ctx, &pb.HealthCheckRequest{})
+	if err != nil {
+		return pb.HealthStatus_UNKNOWN, err
+	}
+	c.setHealth(resp.Status, "", time.Time{})
+	return resp.Status, nil
+}
+
+// BlockStatus asks the pool where the blocks stand in the chain.
+func (c *Client) BlockStatus(ctx context.Context, hashes []string) ([]*pb.BlockInfo, error) {
+	if !c.Supports(pb.Feature_FEATURE_BLOCK_STATUS) {
+		return nil, ErrBlockStatusUnsupported
+	}
+
+	var blocks []*pb.BlockInfo
+	for chunk := range slices.Chunk(hashes, maxStatusHashes) {
+		rpc, opts := c.rpc()
+		res, err := rpc.BlockStatus(ctx, &pb.BlockStatusRequest{Hashes: chunk}, opts...)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, res.Blocks...)
+	}
+	return blocks, nil
+}
+
 func (c *Client) Close() {
+	c.linkMu.RLock()
+	defer c.linkMu.RUnlock()
 	c.conn.Close()
 }
@@ -0,0 +1,788 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package mining
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flokiorg/grpc-miner/common"
+	"github.com/flokiorg/grpc-miner/mining/compress"
+	"github.com/flokiorg/grpc-miner/mining/pb"
+	"github.com/flokiorg/grpc-miner/pool"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+type unavailableStream struct {
+	pb.CandidateStreamClient
+	submits chan struct{}
+}
+
+func (us *unavailableStream) SubmitValidBlock(ctx context.Context, in *pb.ValidBlock, opts ...grpc.CallOption) (*pb.AckBlockSubmited, error) {
+	us.submits <- struct{}{}
+	return nil, status.Error(codes.Unavailable, "pool unreachable")
+}
+
+type legacyPool struct {
+	pb.UnimplementedCandidateStreamServer
+	pb.UnimplementedHealthServer
+}
+
+func (lp *legacyPool) Check(ctx context.Context, in *pb.HealthCheckRequest) (*pb.HealthCheckResponse, error) {
+	return &pb.HealthCheckResponse{Status: pb.HealthStatus_SERVING}, nil
+}
+
+// startPool serves register on a loopback port and returns its address.
+func startPool(t testing.TB, register func(grpc.ServiceRegistrar)) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := grpc.NewServer()
+	register(srv)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	return lis.Addr().String()
+}
+
+func testConfig(addr string) *common.Config {
+	return &common.Config{PoolServer: addr, PoolTimeout: time.Second * 5}
+}
+
+func TestHandshake(t *testing.T) {
+	hello := &pb.HelloRequest{
+		ProtocolVersion: ProtocolVersion,
+		Algorithms:      []string{"scrypt"},
+		Features:        []pb.Feature{pb.Feature_FEATURE_EXTRANONCE, pb.Feature_FEATURE_COMPRESSION},
+		Compressors:     []string{compress.Gzip},
+	}
+
+	tests := []struct {
+		name     string
+		setup    func(*pool.Server)
+		legacy   bool
+		fail     bool
+		protocol uint32
+	}{
+		{"compatible pool", func(s *pool.Server) {}, false, false, ProtocolVersion},
+		{"newer pool", func(s *pool.Server) { s.ProtocolVersion = ProtocolVersion + 1 }, false, false, ProtocolVersion},
+		{"pool requires newer miner", func(s *pool.Server) { s.MinProtocolVersion = ProtocolVersion + 1 }, false, true, 0},
+		{"pool too old", func(s *pool.Server) { s.ProtocolVersion = 0; s.MinProtocolVersion = 0 }, false, true, 0},
+		{"unsupported algorithm", func(s *pool.Server) { s.Algorithms = []string{"sha256"} }, false, true, 0},
+		{"legacy pool", nil, true, false, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			addr := startPool(t, func(registrar grpc.ServiceRegistrar) {
+				if test.legacy {
+					pb.RegisterCandidateStreamServer(registrar, &legacyPool{})
+					pb.RegisterHealthServer(registrar, &legacyPool{})
+					return
+				}
+				server := pool.NewServer(pool.NewMemoryBackend())
+				server.Features = []pb.Feature{pb.Feature_FEATURE_COMPRESSION, pb.Feature_FEATURE_NTIME_ROLLING}
+				test.setup(server)
+				server.Register(registrar)
+			})
+
+			client, err := NewClient(testConfig(addr), hello)
+			if test.fail {
+				if err == nil {
+					client.Close()
+					t.Fatal("expected the handshake to fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			if client.protocol != test.protocol {
+				t.Fatalf("unexpected protocol, want=%d got=%d", test.protocol, client.protocol)
+			}
+			if client.Supports(pb.Feature_FEATURE_EXTRANONCE) || client.Supports(pb.Feature_FEATURE_NTIME_ROLLING) {
+				t.Fatal("negotiated a feature one side does not support")
+			}
+			if client.Supports(pb.Feature_FEATURE_COMPRESSION) == test.legacy {
+				t.Fatalf("unexpected compression support: %v", client.Supports(pb.Feature_FEATURE_COMPRESSION))
+			}
+			want := compress.Gzip
+			if test.legacy {
+				want = ""
+			}
+			if client.Compressor() != want {
+				t.Fatalf("unexpected compressor, want=%q got=%q", want, client.Compressor())
+			}
+		})
+	}
+}
+
+func TestSupersedes(t *testing.T) {
+	block := createCandidateBlock(t, "207fffff")
+
+	refresh := createCandidateBlock(t, "207fffff")
+	refresh.Transactions++
+
+	next := createCandidateBlock(t, "207fffff")
+	next.Height++
+
+	competing := createCandidateBlock(t, "207fffff")
+	competing.Header = competing.Header[:8] + "ff" + competing.Header[10:]
+
+	tests := []struct {
+		name  string
+		tip   *pb.CandidateBlock
+		stale bool
+	}{
+		{"unknown tip", nil, false},
+		{"same template", block, false},
+		{"refreshed template", refresh, false},
+		{"next height", next, true},
+		{"competing parent", competing, true},
+	}
+
+	for _, test := range tests {
+		if got := supersedes(test.tip, block); got != test.stale {
+			t.Fatalf("%s: want=%v got=%v", test.name, test.stale, got)
+		}
+	}
+}
+
+func TestSubmitNonceStale(t *testing.T) {
+	stream := &unavailableStream{submits: make(chan struct{}, 10)}
+	client := &Client{
+		stream:     stream,
+		tipChanged: make(chan struct{}),
+	}
+
+	block := createCandidateBlock(t, "207fffff")
+	client.setTip(block)
+
+	next := createCandidateBlock(t, "207fffff")
+	next.Height++
+
+	go func() {
+		<-stream.submits
+		client.setTip(next)
+	}()
+
+	start := time.Now()
+	_, err := client.SubmitNonce(context.Background(), block, 1, 10, 30)
+	if !errors.Is(err, ErrStaleSolution) {
+		t.Fatalf("expected a stale solution error, got=%v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("stale submission was not aborted promptly: %s", elapsed)
+	}
+}
+
+func TestSubmitNonceSessionDown(t *testing.T) {
+	addr := startPool(t, pool.NewServer(pool.NewMemoryBackend()).Register)
+	hello := &pb.HelloRequest{ProtocolVersion: ProtocolVersion, Features: []pb.Feature{pb.Feature_FEATURE_SESSION}}
+	client, err := NewClient(testConfig(addr), hello)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	// waits are capped by the backoff, not the pool timeout
+	client.dialTimeout = time.Hour
+	client.MinBackoff = time.Millisecond
+
+	// without Listen the session never opens
+	start := time.Now()
+	_, err = client.SubmitNonce(context.Background(), createCandidateBlock(t, "207fffff"), 1, 2, 0.01)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("session waits not capped by the backoff: %s", elapsed)
+	}
+	if !errors.Is(err, errSessionDown) || !isTransientError(err) {
+		t.Fatalf("expected a transient session error, got=%v", err)
+	}
+	if !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Fatalf("session waits not counted as attempts: %v", err)
+	}
+}
+
+// lostAckBackend takes the first solution but fails to answer it.
+type lostAckBackend struct {
+	*pool.MemoryBackend
+	lost atomic.Bool
+}
+
+func (lb *lostAckBackend) Submit(ctx context.Context, block *pb.CandidateBlock, nonce uint32) (*pb.AckBlockSubmited, error) {
+	ack, err := lb.MemoryBackend.Submit(ctx, block, nonce)
+	if err == nil && lb.lost.CompareAndSwap(false, true) {
+		return nil, status.Error(codes.Unavailable, "ack lost")
+	}
+	return ack, err
+}
+
+func TestSubmitNonceLostAck(t *testing.T) {
+	backend := &lostAckBackend{MemoryBackend: pool.NewMemoryBackend()}
+	addr := startPool(t, pool.NewServer(backend).Register)
+	client, err := NewClient(testConfig(addr), &pb.HelloRequest{ProtocolVersion: ProtocolVersion})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.MinBackoff = time.Millisecond
+
+	// the retry is refused as a duplicate of the solution the pool already took
+	ack, err := client.SubmitNonce(context.Background(), createCandidateBlock(t, "207fffff"), 42, 2, 0.01)
+	if err != nil {
+		t.Fatalf("a duplicate of an earlier attempt should count as accepted: %v", err)
+	}
+	if submitted := backend.Submitted(); len(submitted) != 1 || submitted[0].Header != ack.Header {
+		t.Fatalf("unexpected submissions %+v for ack %s", submitted, ack.Header)
+	}
+}
+
+func TestSession(t *testing.T) {
+	for _, session := range []bool{true, false} {
+		t.Run(fmt.Sprintf("session=%v", session), func(t *testing.T) {
+			backend := pool.NewMemoryBackend()
+			server := pool.NewServer(backend)
+			if !session {
+				server.Features = nil
+			}
+			addr := startPool(t, server.Register)
+
+			hello := &pb.HelloRequest{ProtocolVersion: ProtocolVersion, Features: []pb.Feature{pb.Feature_FEATURE_SESSION}}
+			client, err := NewClient(testConfig(addr), hello)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			if client.Supports(pb.Feature_FEATURE_SESSION) != session {
+				t.Fatalf("unexpected session support: %v", client.Supports(pb.Feature_FEATURE_SESSION))
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			template := createCandidateBlock(t, "207fffff")
+			backend.Push(template)
+
+			blocks := make(chan *pb.CandidateBlock)
+			worker := &pb.Worker{Name: "rig-01", RigId: "4f2a9c1e07b3", Algorithm: "scrypt_cpu", Threads: 4}
+			go client.Listen(ctx, &pb.CandidateRequest{Worker: worker}, blocks)
+
+			var block *pb.CandidateBlock
+			select {
+			case block = <-blocks:
+			case <-time.After(5 * time.Second):
+				t.Fatal("no candidate block received")
+			}
+			if block.JobId == "" || !block.CleanJobs {
+				t.Fatalf("expected a clean job, got id=%q clean=%v", block.JobId, block.CleanJobs)
+			}
+
+			ack, err := client.SubmitNonce(ctx, block, 42, 3, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if submitted := backend.Submitted(); len(submitted) != 1 || submitted[0].Nonce != 42 || submitted[0].Header != ack.Header {
+				t.Fatalf("unexpected submissions: %+v", submitted)
+			}
+
+			_, err = client.SubmitNonce(ctx, block, 42, 0, 1)
+			if status.Code(errors.Unwrap(err)) != codes.AlreadyExists || isTransientError(err) {
+				t.Fatalf("expected the duplicate to be rejected, got=%v", err)
+			}
+
+			refresh := createCandidateBlock(t, "207fffff")
+			refresh.Transactions++
+			backend.Push(refresh)
+
+			select {
+			case block = <-blocks:
+			case <-time.After(5 * time.Second):
+				t.Fatal("no refreshed block received")
+			}
+			if block.CleanJobs {
+				t.Fatal("a refresh on the same parent should not void previous jobs")
+			}
+
+			block.JobId = "expired"
+			_, err = client.SubmitNonce(ctx, block, 7, 0, 1)
+			if status.Code(errors.Unwrap(err)) != codes.NotFound {
+				t.Fatalf("expected an unknown job to be rejected, got=%v", err)
+			}
+
+			workers := server.Workers()
+			if len(workers) != 1 || workers[0].Name != worker.Name || workers[0].RigID != worker.RigId || workers[0].Threads != 4 {
+				t.Fatalf("unexpected workers: %+v", workers)
+			}
+			if session && (workers[0].Accepted != 1 || workers[0].Rejected != 2) {
+				t.Fatalf("solutions not attributed to the worker: %+v", workers[0])
+			}
+		})
+	}
+}
+
+// floodBackend hands a stream asking for the "flood" address more jobs than
+// the pool keeps, on top of the templates of the memory backend.
+type floodBackend struct {
+	*pool.MemoryBackend
+	template *pb.CandidateBlock
+}
+
+func (fb *floodBackend) Templates(ctx context.Context, request *pb.CandidateRequest) (<-chan *pb.CandidateBlock, error) {
+	if len(request.MiningAddrs) == 0 || request.MiningAddrs[0] != "flood" {
+		return fb.MemoryBackend.Templates(ctx, request)
+	}
+	templates := make(chan *pb.CandidateBlock, 64)
+	for i := range cap(templates) {
+		block := proto.Clone(fb.template).(*pb.CandidateBlock)
+		block.JobId = fmt.Sprintf("flood-%d", i)
+		block.CleanJobs = false
+		templates <- block
+	}
+	return templates, nil
+}
+
+func TestSubmitCompactPerConnection(t *testing.T) {
+	template := createCandidateBlock(t, "207fffff")
+	backend := &floodBackend{MemoryBackend: pool.NewMemoryBackend(), template: template}
+	backend.Push(template)
+	addr := startPool(t, pool.NewServer(backend).Register)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	listen := func(address string, n int) (*Client, []*pb.CandidateBlock) {
+		t.Helper()
+		client, err := NewClient(testConfig(addr), &pb.HelloRequest{ProtocolVersion: ProtocolVersion})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { client.Close() })
+
+		blocks := make(chan *pb.CandidateBlock)
+		go client.Listen(ctx, &pb.CandidateRequest{MiningAddrs: []string{address}}, blocks)
+		received := make([]*pb.CandidateBlock, n)
+		for i := range received {
+			select {
+			case received[i] = <-blocks:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("no candidate block for %s", address)
+			}
+		}
+		return client, received
+	}
+
+	miner, jobs := listen("ours", 1)
+	flooder, _ := listen("flood", 64)
+
+	// the jobs streamed to another miner do not evict ours
+	if _, err := miner.SubmitNonce(ctx, jobs[0], 1, 0, 1); err != nil {
+		t.Fatalf("solution to our job rejected: %v", err)
+	}
+	_, err := flooder.SubmitNonce(ctx, jobs[0], 2, 0, 1)
+	if status.Code(errors.Unwrap(err)) != codes.NotFound {
+		t.Fatalf("expected the job of another connection to be unknown, got=%v", err)
+	}
+}
+
+func TestRedirect(t *testing.T) {
+	origin, target := pool.NewServer(pool.NewMemoryBackend()), pool.NewMemoryBackend()
+	originAddr := startPool(t, origin.Register)
+	targetAddr := startPool(t, pool.NewServer(target).Register)
+
+	block := createCandidateBlock(t, "207fffff")
+	target.Push(block)
+
+	hello := &pb.HelloRequest{ProtocolVersion: ProtocolVersion, Features: []pb.Feature{pb.Feature_FEATURE_SESSION}}
+	client, err := NewClient(testConfig(originAddr), hello)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	events := make(chan Event, 16)
+	bus := NewBus()
+	bus.Subscribe(func(e Event) { events <- e })
+	client.SetEvents(bus)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	blocks := make(chan *pb.CandidateBlock)
+	go client.Listen(ctx, &pb.CandidateRequest{}, blocks)
+
+	for deadline := time.Now().Add(5 * time.Second); origin.Peers() == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("session not opened")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	origin.Notify("moving to a new server")
+	origin.Maintenance("upgrade", time.Now().Add(time.Hour))
+	origin.Redirect(targetAddr, 0)
+
+	select {
+	case received := <-blocks:
+		if received.Header != block.Header {
+			t.Fatalf("unexpected block: %+v", received)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no candidate block received from the redirected pool")
+	}
+	if client.Address() != targetAddr {
+		t.Fatalf("unexpected address, want=%s got=%s", targetAddr, client.Address())
+	}
+
+	var got []Event
+	for len(events) > 0 {
+		e := <-events
+		if changed, ok := e.(HealthChanged); ok {
+			changed.Time, changed.Until = time.Time{}, time.Time{}
+			e = changed
+		}
+		if switched, ok := e.(PoolSwitched); ok {
+			switched.Time = time.Time{}
+			e = switched
+		}
+		got = append(got, e)
+	}
+	want := []Event{
+		HealthChanged{Pool: originAddr, Status: pb.HealthStatus_MAINTENANCE, Message: "upgrade"},
+		HealthChanged{Pool: targetAddr, Status: pb.HealthStatus_SERVING},
+		PoolSwitched{From: originAddr, To: targetAddr},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected events\nwant=%+v\n got=%+v", want, got)
+	}
+}
+
+// recordingBackend reports every request templates are asked for.
+type recordingBackend struct {
+	*pool.MemoryBackend
+	requests chan *pb.CandidateRequest
+}
+
+func (rb *recordingBackend) Templates(ctx context.Context, request *pb.CandidateRequest) (<-chan *pb.CandidateBlock, error) {
+	rb.requests <- request
+	return rb.MemoryBackend.Templates(ctx, request)
+}
+
+func TestRenew(t *testing.T) {
+	for _, session := range []bool{true, false} {
+		t.Run(fmt.Sprintf("session=%v", session), func(t *testing.T) {
+			backend := &recordingBackend{MemoryBackend: pool.NewMemoryBackend(), requests: make(chan *pb.CandidateRequest, 4)}
+			backend.Push(createCandidateBlock(t, "207fffff"))
+			server := &countingPool{Server: pool.NewServer(backend)}
+			addr := startPool(t, func(registrar grpc.ServiceRegistrar) {
+				pb.RegisterCandidateStreamServer(registrar, server)
+				pb.RegisterHealthServer(registrar, server)
+			})
+
+			hello := &pb.HelloRequest{ProtocolVersion: ProtocolVersion}
+			if session {
+				hello.Features = []pb.Feature{pb.Feature_FEATURE_SESSION}
+			}
+			client, err := NewClient(testConfig(addr), hello)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			blocks := make(chan *pb.CandidateBlock)
+			go client.Listen(ctx, &pb.CandidateRequest{MiningAddrs: []string{"first"}}, blocks)
+
+			for _, want := range []string{"first", "second"} {
+				select {
+				case request := <-backend.requests:
+					if request.MiningAddrs[0] != want {
+						t.Fatalf("unexpected request, want=%s got=%v", want, request.MiningAddrs)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatalf("no request for %s", want)
+				}
+				select {
+				case <-blocks:
+				case <-time.After(5 * time.Second):
+					t.Fatalf("no template for %s", want)
+				}
+				client.Renew(&pb.CandidateRequest{MiningAddrs: []string{"second"}})
+			}
+
+			// a session takes the renewed request without being reopened
+			if session && server.sessions.Load() != 1 {
+				t.Fatalf("unexpected sessions, want=1 got=%d", server.sessions.Load())
+			}
+		})
+	}
+}
+
+// countingPool counts sessions, and never answers them when silent.
+type countingPool struct {
+	*pool.Server
+	silent   bool
+	sessions atomic.Int32
+}
+
+func (cp *countingPool) Session(stream pb.CandidateStream_SessionServer) error {
+	cp.sessions.Add(1)
+	if !cp.silent {
+		return cp.Server.Session(stream)
+	}
+	for {
+		if _, err := stream.Recv(); err != nil {
+			return err
+		}
+	}
+}
+
+func TestDeadStreamDetection(t *testing.T) {
+	tests := []struct {
+		name       string
+		silent     bool
+		reconnects bool
+	}{
+		{"heartbeats keep the session alive", false, false},
+		{"silent pool", true, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := &countingPool{Server: pool.NewServer(pool.NewMemoryBackend()), silent: test.silent}
+			addr := startPool(t, func(registrar grpc.ServiceRegistrar) {
+				pb.RegisterCandidateStreamServer(registrar, server)
+				pb.RegisterHealthServer(registrar, server)
+			})
+
+			hello := &pb.HelloRequest{ProtocolVersion: ProtocolVersion, Features: []pb.Feature{pb.Feature_FEATURE_SESSION}}
+			client, err := NewClient(testConfig(addr), hello)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			client.HeartbeatInterval = 50 * time.Millisecond
+			client.MaxSilence = 300 * time.Millisecond
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go client.Listen(ctx, &pb.CandidateRequest{}, make(chan *pb.CandidateBlock))
+
+			time.Sleep(3 * time.Second)
+			if reconnected := server.sessions.Load() > 1; reconnected != test.reconnects {
+				t.Fatalf("unexpected reconnection, want=%v got=%d sessions", test.reconnects, server.sessions.Load())
+			}
+		})
+	}
+}
+
+func TestListenCancelDuringBackoff(t *testing.T) {
+	addr := startPool(t, func(registrar grpc.ServiceRegistrar) {
+		pb.RegisterCandidateStreamServer(registrar, &legacyPool{})
+		pb.RegisterHealthServer(registrar, &legacyPool{})
+	})
+
+	client, err := NewClient(testConfig(addr), &pb.HelloRequest{ProtocolVersion: ProtocolVersion})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	client.MinBackoff = time.Minute
+	client.MaxBackoff = time.Minute
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		client.Listen(ctx, &pb.CandidateRequest{}, make(chan *pb.CandidateBlock))
+		close(stopped)
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Listen ignored cancellation while backing off")
+	}
+}
+
+// droppingPool lets tests drop the current session as a broken connection would.
+type droppingPool struct {
+	*pool.Server
+	drop chan context.CancelFunc
+}
+
+type droppableStream struct {
+	pb.CandidateStream_SessionServer
+	ctx context.Context
+}
+
+func (ds *droppableStream) Context() context.Context {
+	return ds.ctx
+}
+
+func (dp *droppingPool) Session(stream pb.CandidateStream_SessionServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	dp.drop <- cancel
+	return dp.Server.Session(&droppableStream{CandidateStream_SessionServer: stream, ctx: ctx})
+}
+
+func TestSessionResume(t *testing.T) {
+	for _, resume := range []bool{true, false} {
+		t.Run(fmt.Sprintf("resume=%v", resume), func(t *testing.T) {
+			backend := pool.NewMemoryBackend()
+			server := &droppingPool{Server: pool.NewServer(backend), drop: make(chan context.CancelFunc, 2)}
+			addr := startPool(t, func(registrar grpc.ServiceRegistrar) {
+				pb.RegisterCandidateStreamServer(registrar, server)
+				pb.RegisterHealthServer(registrar, server)
+			})
+
+			hello := &pb.HelloRequest{ProtocolVersion: ProtocolVersion, Features: []pb.Feature{pb.Feature_FEATURE_SESSION}}
+			client, err := NewClient(testConfig(addr), hello)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+			client.MinBackoff = 10 * time.Millisecond
+
+			reconnected := make(chan StreamReconnected, 1)
+			bus := NewBus()
+			bus.Subscribe(func(e Event) {
+				if e, ok := e.(StreamReconnected); ok {
+					reconnected <- e
+				}
+			})
+			client.SetEvents(bus)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			blocks := make(chan *pb.CandidateBlock)
+			go client.Listen(ctx, &pb.CandidateRequest{}, blocks)
+
+			receive := func() *pb.CandidateBlock {
+				t.Helper()
+				select {
+				case block := <-blocks:
+					return block
+				case <-time.After(5 * time.Second):
+					t.Fatal("no candidate block received")
+					return nil
+				}
+			}
+
+			backend.Push(createCandidateBlock(t, "207fffff"))
+			first := receive()
+
+			refresh := createCandidateBlock(t, "207fffff")
+			refresh.Transactions++
+			backend.Push(refresh)
+			current := receive()
+
+			// drop the connection, the pool re-sends the current job on the next session
+			if !resume {
+				client.sessionMu.Lock()
+				client.resumeToken = ""
+				client.sessionMu.Unlock()
+			}
+			(<-server.drop)()
+			if resent := receive(); resent.JobId != current.JobId {
+				t.Fatalf("unexpected job after reconnection, want=%s got=%s", current.JobId, resent.JobId)
+			}
+			select {
+			case e := <-reconnected:
+				if e.Resumed != resume || e.Pool != addr {
+					t.Fatalf("unexpected reconnection %+v", e)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("reconnection not published")
+			}
+
+			_, err = client.SubmitNonce(ctx, first, 1, 0, 1)
+			if resume && err != nil {
+				t.Fatalf("solution to a job of the previous connection rejected: %v", err)
+			}
+			if !resume && status.Code(errors.Unwrap(err)) != codes.NotFound {
+				t.Fatalf("expected the job to be unknown to a new session, got=%v", err)
+			}
+		})
+	}
+}
+
+// echoBackend acknowledges every submission without looking at it.
+type echoBackend struct {
+	pool.Backend
+}
+
+func (echoBackend) Submit(ctx context.Context, block *pb.CandidateBlock, nonce uint32) (*pb.AckBlockSubmited, error) {
+	return &pb.AckBlockSubmited{Header: block.Header}, nil
+}
+
+// BenchmarkSubmitCompression measures the round trip of a full block
+// submission to a loopback pool with each compressor.
+func BenchmarkSubmitCompression(b *testing.B) {
+	// Half random like signatures and hashes, half repetitive like scripts
+	rng := rand.New(rand.NewPCG(1, 2))
+	payload := make([]byte, 1<<20)
+	for i := range payload {
+		if i%2 == 0 {
+			payload[i] = byte(rng.Uint32())
+		} else {
+			payload[i] = byte(i % 16)
+		}
+	}
+	block := &pb.CandidateBlock{Bits: "207fffff", Header: strings.Repeat("00", 80), Height: 1, Block: payload}
+
+	for _, mode := range []string{compress.None, compress.Gzip, compress.Zstd} {
+		b.Run(mode, func(b *testing.B) {
+			addr := startPool(b, func(registrar grpc.ServiceRegistrar) {
+				pool.NewServer(echoBackend{}).Register(registrar)
+			})
+
+			cfg := testConfig(addr)
+			cfg.Compression = mode
+			compressors, _ := compress.Preference(mode)
+			hello := &pb.HelloRequest{
+				ProtocolVersion: ProtocolVersion,
+				Features:        []pb.Feature{pb.Feature_FEATURE_COMPRESSION},
+				Compressors:     compressors,
+			}
+			client, err := NewClient(cfg, hello)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer client.Close()
+
+			b.SetBytes(int64(len(payload)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := client.submit(context.Background(), block, uint32(i)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
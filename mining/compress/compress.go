@@ -0,0 +1,114 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+// Package compress registers the gRPC compressors miners and pools may
+// negotiate during the handshake.
+package compress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+const (
+	Zstd = "zstd"
+	Gzip = gzip.Name
+	None = "none"
+	Auto = "auto"
+)
+
+// Supported lists the registered compressors by order of preference.
+var Supported = []string{Zstd, Gzip}
+
+func init() {
+	encoding.RegisterCompressor(&zstdCompressor{})
+}
+
+// Preference turns a configured compression mode into the list of
+// compressors to offer the pool. It is empty when compression is disabled.
+func Preference(mode string) ([]string, error) {
+	switch strings.ToLower(mode) {
+	case "", None:
+		return nil, nil
+	case Auto:
+		return Supported, nil
+	case Zstd, Gzip:
+		return []string{strings.ToLower(mode)}, nil
+	}
+	return nil, fmt.Errorf("unknown compression %q, expected auto, zstd, gzip or none", mode)
+}
+
+// zstdCompressor favours speed over ratio, templates are latency sensitive.
+type zstdCompressor struct {
+	encoders sync.Pool
+	decoders sync.Pool
+}
+
+func (c *zstdCompressor) Name() string {
+	return Zstd
+}
+
+func (c *zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	encoder, _ := c.encoders.Get().(*zstd.Encoder)
+	if encoder == nil {
+		var err error
+		encoder, err = zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		encoder.Reset(w)
+	}
+	return &zstdWriter{Encoder: encoder, pool: &c.encoders}, nil
+}
+
+func (c *zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	decoder, _ := c.decoders.Get().(*zstd.Decoder)
+	if decoder == nil {
+		var err error
+		decoder, err = zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+	} else if err := decoder.Reset(r); err != nil {
+		c.decoders.Put(decoder)
+		return nil, err
+	}
+	return &zstdReader{Decoder: decoder, pool: &c.decoders}, nil
+}
+
+type zstdWriter struct {
+	*zstd.Encoder
+	pool *sync.Pool
+}
+
+func (w *zstdWriter) Close() error {
+	err := w.Encoder.Close()
+	w.pool.Put(w.Encoder)
+	return err
+}
+
+type zstdReader struct {
+	*zstd.Decoder
+	pool *sync.Pool
+}
+
+// Read returns the decoder to the pool once the message is fully read.
+func (r *zstdReader) Read(p []byte) (int, error) {
+	if r.Decoder == nil {
+		return 0, io.EOF
+	}
+	n, err := r.Decoder.Read(p)
+	if err == io.EOF {
+		r.pool.Put(r.Decoder)
+		r.Decoder = nil
+	}
+	return n, err
+}
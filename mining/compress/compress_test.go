@@ -0,0 +1,74 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package compress
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"testing"
+
+	"google.golang.org/grpc/encoding"
+)
+
+func TestRoundTrip(t *testing.T) {
+	payload := bytes.Repeat([]byte("flokicoin block template "), 4096)
+
+	for _, name := range Supported {
+		compressor := encoding.GetCompressor(name)
+		if compressor == nil {
+			t.Fatalf("%s is not registered", name)
+		}
+
+		// twice, so pooled encoders and decoders are reused
+		for i := 0; i < 2; i++ {
+			var buf bytes.Buffer
+			w, err := compressor.Compress(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Write(payload)
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if buf.Len() >= len(payload) {
+				t.Fatalf("%s did not compress: %d bytes", name, buf.Len())
+			}
+
+			r, err := compressor.Decompress(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, payload) {
+				t.Fatalf("%s round trip mismatch", name)
+			}
+		}
+	}
+}
+
+func TestPreference(t *testing.T) {
+	tests := []struct {
+		mode string
+		want []string
+		fail bool
+	}{
+		{"auto", Supported, false},
+		{"GZIP", []string{Gzip}, false},
+		{"none", nil, false},
+		{"", nil, false},
+		{"brotli", nil, true},
+	}
+
+	for _, test := range tests {
+		got, err := Preference(test.mode)
+		if (err != nil) != test.fail || !slices.Equal(got, test.want) {
+			t.Fatalf("%q: want=%v fail=%v, got=%v err=%v", test.mode, test.want, test.fail, got, err)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package mining
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/flokiorg/grpc-miner/mining/pb"
+)
+
+// Event is something that happened to the miner or to its link with the pool.
+// Subscribers switch on the event types below.
+type Event interface {
+	event()
+}
+
+// TemplateReceived is a template sent by the pool, before it is verified and
+// the miner decides whether to mine it.
+type TemplateReceived struct {
+	Time  time.Time
+	Block *pb.CandidateBlock
+}
+
+// MiningStarted is the workers starting on a template.
+type MiningStarted struct {
+	Time    time.Time
+	Block   *pb.CandidateBlock
+	Threads uint8
+}
+
+// SolutionFound is a nonce meeting the target of the template, about to be submitted.
+type SolutionFound struct {
+	Time  time.Time
+	Block *pb.CandidateBlock
+	Nonce uint32
+	Hash  string
+}
+
+// SubmitAccepted is a solution the pool accepted, Hash being the block hash.
+type SubmitAccepted struct {
+	Time  time.Time
+	Block *pb.CandidateBlock
+	Nonce uint32
+	Hash  string
+}
+
+// SubmitRejected is a solution the pool did not accept. A solution the pool
+// could not be reached for is kept in the journal and Retry is set.
+type SubmitRejected struct {
+	Time  time.Time
+	Block *pb.CandidateBlock
+	Nonce uint32
+	Err   error
+	Retry bool
+}
+
+// Stale reports whether the chain moved past the solution before it was accepted.
+func (e SubmitRejected) Stale() bool {
+	return errors.Is(e.Err, ErrStaleSolution)
+}
+
+// StreamReconnected is the job stream reopened after it broke. Resumed is set
+// when the pool kept the session, and with it the earlier jobs.
+type StreamReconnected struct {
+	Time    time.Time
+	Pool    string
+	Resumed bool
+}
+
+// PoolSwitched is the client following a redirect to another pool server.
+type PoolSwitched struct {
+	Time time.Time
+	From string
+	To   string
+}
+
+// HealthChanged is the pool reporting a new status, or the client inferring
+// one from the job stream. Until is when a maintenance ends, if known.
+type HealthChanged struct {
+	Time    time.Time
+	Pool    string
+	Status  pb.HealthStatus
+	Message string
+	Until   time.Time
+}
+
+func (TemplateReceived) event()  {}
+func (MiningStarted) event()     {}
+func (SolutionFound) event()     {}
+func (SubmitAccepted) event()    {}
+func (SubmitRejected) event()    {}
+func (StreamReconnected) event() {}
+func (PoolSwitched) event()      {}
+func (HealthChanged) event()     {}
+
+// Bus hands every event published to its subscribers. A nil Bus drops events.
+type Bus struct {
+	mu   sync.RWMutex
+	subs []*subscription
+
+	// the miner, its client and submissions publish from their own goroutines,
+	// a single one at a time drains what they queued
+	queueMu  sync.Mutex
+	queue    []Event
+	draining bool
+	drained  *sync.Cond
+}
+
+type subscription struct {
+	handler func(Event)
+}
+
+func NewBus() *Bus {
+	b := &Bus{}
+	b.drained = sync.NewCond(&b.queueMu)
+	return b
+}
+
+// Subscribe calls handler with every event published from now on. Events are
+// delivered one at a time in the order they were published, on a goroutine of
+// the bus: publishers never wait for handlers, handlers may publish but should
+// not block as the events after theirs wait for them. Calling the returned
+// function unsubscribes it.
+func (b *Bus) Subscribe(handler func(Event)) func() {
+	sub := &subscription{handler: handler}
+
+	b.mu.Lock()
+	b.subs = append(b.subs, sub)
+	b.mu.Unlock()
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		// the bus may still be walking the current slice
+		b.subs = slices.DeleteFunc(slices.Clone(b.subs), func(s *subscription) bool { return s == sub })
+	}
+}
+
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+
+	b.queueMu.Lock()
+	defer b.queueMu.Unlock()
+
+	b.queue = append(b.queue, event)
+	if !b.draining {
+		b.draining = true
+		go b.drain()
+	}
+}
+
+// Wait returns once every event published so far was delivered. Handlers must
+// not call it.
+func (b *Bus) Wait() {
+	if b == nil {
+		return
+	}
+
+	b.queueMu.Lock()
+	defer b.queueMu.Unlock()
+	for b.draining {
+		b.drained.Wait()
+	}
+}
+
+func (b *Bus) drain() {
+	for {
+		b.queueMu.Lock()
+		if len(b.queue) == 0 {
+			b.draining = false
+			b.drained.Broadcast()
+			b.queueMu.Unlock()
+			return
+		}
+		event := b.queue[0]
+		b.queue[0] = nil
+		b.queue = b.queue[1:]
+		b.queueMu.Unlock()
+
+		b.mu.RLock()
+		subs := b.subs
+		b.mu.RUnlock()
+
+		for _, sub := range subs {
+			sub.handler(event)
+		}
+	}
+}
+
+// EventSource is implemented by pool clients publishing events of their own,
+// the Miner hands them its bus before listening.
+type EventSource interface {
+	SetEvents(events *Bus)
+}
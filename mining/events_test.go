@@ -0,0 +1,123 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package mining
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBus(t *testing.T) {
+	var nilBus *Bus
+	nilBus.Publish(TemplateReceived{})
+	nilBus.Wait()
+
+	bus := NewBus()
+	var first, second []Event
+	unsubscribe := bus.Subscribe(func(e Event) { first = append(first, e) })
+	bus.Subscribe(func(e Event) { second = append(second, e) })
+
+	bus.Publish(PoolSwitched{From: "a", To: "b"})
+	bus.Wait()
+	unsubscribe()
+	bus.Publish(SubmitRejected{Err: errors.Join(errors.New("ack lost"), ErrStaleSolution)})
+	bus.Wait()
+
+	if len(first) != 1 || first[0] != (PoolSwitched{From: "a", To: "b"}) {
+		t.Fatalf("unexpected events before unsubscribing %v", first)
+	}
+	if len(second) != 2 {
+		t.Fatalf("unexpected events %v", second)
+	}
+	if rejected, ok := second[1].(SubmitRejected); !ok || !rejected.Stale() {
+		t.Fatalf("stale rejection expected, got %v", second[1])
+	}
+}
+
+func TestBusUnsubscribeWhilePublishing(t *testing.T) {
+	bus := NewBus()
+	var calls int
+	var unsubscribe func()
+	unsubscribe = bus.Subscribe(func(Event) {
+		calls++
+		unsubscribe()
+	})
+	bus.Subscribe(func(Event) { calls++ })
+
+	bus.Publish(TemplateReceived{})
+	bus.Publish(TemplateReceived{})
+	bus.Wait()
+	if calls != 3 {
+		t.Fatalf("unexpected handler calls, want=3 got=%d", calls)
+	}
+}
+
+func TestBusConcurrentPublishers(t *testing.T) {
+	bus := NewBus()
+	// handlers need no locking of their own
+	var events []Event
+	bus.Subscribe(func(e Event) { events = append(events, e) })
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				bus.Publish(TemplateReceived{})
+			}
+		}()
+	}
+	wg.Wait()
+	bus.Wait()
+
+	if len(events) != 800 {
+		t.Fatalf("unexpected events, want=800 got=%d", len(events))
+	}
+}
+
+func TestBusPublishFromHandler(t *testing.T) {
+	bus := NewBus()
+	var events []Event
+	bus.Subscribe(func(e Event) {
+		events = append(events, e)
+		if _, ok := e.(PoolSwitched); ok {
+			bus.Publish(StreamReconnected{Pool: "b"})
+		}
+	})
+
+	bus.Publish(PoolSwitched{From: "a", To: "b"})
+	bus.Publish(TemplateReceived{})
+	bus.Wait()
+
+	want := []Event{PoolSwitched{From: "a", To: "b"}, TemplateReceived{}, StreamReconnected{Pool: "b"}}
+	if !slices.Equal(events, want) {
+		t.Fatalf("unexpected events\nwant=%v\n got=%v", want, events)
+	}
+}
+
+func TestBusSlowHandler(t *testing.T) {
+	bus := NewBus()
+	release := make(chan struct{})
+	bus.Subscribe(func(Event) { <-release })
+
+	// publishers do not wait for the handler
+	published := make(chan struct{})
+	go func() {
+		bus.Publish(TemplateReceived{})
+		bus.Publish(TemplateReceived{})
+		close(published)
+	}()
+	select {
+	case <-published:
+	case <-time.After(5 * time.Second):
+		t.Fatal("publishing waited for a slow handler")
+	}
+	close(release)
+	bus.Wait()
+}
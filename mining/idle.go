@@ -0,0 +1,252 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package mining
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flokiorg/grpc-miner/common"
+	"github.com/rs/zerolog"
+)
+
+const (
+	pauseReasonIdle = "idle"
+
+	procStatPath     = "/proc/stat"
+	procSelfStatPath = "/proc/self/stat"
+	procLoadavgPath  = "/proc/loadavg"
+)
+
+// idleSample is a snapshot of how busy the machine is without our own workers.
+type idleSample struct {
+	otherCPU      float64       // share of CPU time used by other processes since the previous sample (0-1)
+	otherRunnable float64       // runnable tasks not belonging to us, per CPU
+	inputIdle     time.Duration // time since the last user input, negative if unknown
+}
+
+// IdleMonitor pauses the miner while other processes or a user need the
+// machine, and resumes it once the machine has been idle long enough.
+type IdleMonitor struct {
+	cfg    *common.Config
+	miner  *Miner
+	logger zerolog.Logger
+
+	sample func() (idleSample, error)
+
+	prevTotal, prevIdle, prevSelf uint64
+	idleSince                     time.Time
+}
+
+func NewIdleMonitor(cfg *common.Config, miner *Miner, logger zerolog.Logger) *IdleMonitor {
+	im := &IdleMonitor{
+		cfg:    cfg,
+		miner:  miner,
+		logger: logger,
+	}
+	im.sample = im.readSample
+	return im
+}
+
+func (im *IdleMonitor) Run(ctx context.Context) {
+	// prime the CPU counters so the first real sample covers a full interval
+	if _, err := im.sample(); err != nil {
+		im.logger.Warn().Err(err).Msg("idle detection unavailable, mining without it")
+		return
+	}
+
+	im.logger.Info().Msgf("💤 idle mode enabled (cpu high:%.2f low:%.2f runnable:%.2f input:%s resume after:%s)",
+		im.cfg.IdleCPUHigh, im.cfg.IdleCPULow, im.cfg.IdleRunnable, im.cfg.IdleInputTime, im.cfg.IdleResumeDelay)
+
+	ticker := time.NewTicker(im.cfg.IdleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s, err := im.sample()
+			if err != nil {
+				im.logger.Warn().Err(err).Msg("failed to sample system load")
+				continue
+			}
+			im.step(s, time.Now())
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// step applies the hysteresis: mining pauses as soon as the machine gets busy,
+// and only resumes after it stayed below the low watermarks for IdleResumeDelay.
+func (im *IdleMonitor) step(s idleSample, now time.Time) {
+	inputActive := s.inputIdle >= 0 && s.inputIdle < im.cfg.IdleInputTime
+
+	if s.otherCPU >= im.cfg.IdleCPUHigh || inputActive {
+		im.idleSince = time.Time{}
+		if !im.miner.Paused() {
+			im.logger.Info().Msgf("🖥️ machine busy (cpu:%.2f input idle:%s), yielding", s.otherCPU, formatInputIdle(s.inputIdle))
+		}
+		im.miner.Pause(pauseReasonIdle)
+		return
+	}
+
+	if s.otherCPU > im.cfg.IdleCPULow || s.otherRunnable > im.cfg.IdleRunnable {
+		im.idleSince = time.Time{}
+		return
+	}
+
+	if im.idleSince.IsZero() {
+		im.idleSince = now
+	}
+	if now.Sub(im.idleSince) >= im.cfg.IdleResumeDelay {
+		im.miner.Resume(pauseReasonIdle)
+	}
+}
+
+func (im *IdleMonitor) readSample() (idleSample, error) {
+	total, idle, err := readProcStat(procStatPath)
+	if err != nil {
+		return idleSample{}, err
+	}
+	self, err := readSelfStat(procSelfStatPath)
+	if err != nil {
+		return idleSample{}, err
+	}
+	runnable, err := readRunnable(procLoadavgPath)
+	if err != nil {
+		return idleSample{}, err
+	}
+
+	s := idleSample{inputIdle: readInputIdle()}
+
+	if dTotal := total - im.prevTotal; im.prevTotal > 0 && dTotal > 0 {
+		busy := int64(dTotal) - int64(idle-im.prevIdle) - int64(self-im.prevSelf)
+		s.otherCPU = max(0, float64(busy)/float64(dTotal))
+	}
+	im.prevTotal, im.prevIdle, im.prevSelf = total, idle, self
+
+	// the reading thread is runnable too
+	ours := 1
+	if !im.miner.Paused() {
+		ours += int(im.cfg.Threads)
+	}
+	s.otherRunnable = max(0, float64(runnable-ours)/float64(runtime.NumCPU()))
+
+	return s, nil
+}
+
+// readProcStat returns the total and idle jiffies of the aggregated cpu line.
+func readProcStat(path string) (uint64, uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	return parseProcStat(string(data))
+}
+
+func parseProcStat(data string) (uint64, uint64, error) {
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || fields[0] != "cpu" {
+			continue
+		}
+
+		var total, idle uint64
+		// user nice system idle iowait irq softirq steal; guest time is already part of user
+		for i := 1; i < len(fields) && i <= 8; i++ {
+			v, err := strconv.ParseUint(fields[i], 10, 64)
+			if err != nil {
+				return 0, 0, fmt.Errorf("invalid cpu field %q: %v", fields[i], err)
+			}
+			total += v
+			if i == 4 || i == 5 {
+				idle += v
+			}
+		}
+		return total, idle, nil
+	}
+	return 0, 0, errors.New("cpu line not found")
+}
+
+// readSelfStat returns the user+system jiffies consumed by this process.
+func readSelfStat(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return parseSelfStat(string(data))
+}
+
+func parseSelfStat(data string) (uint64, error) {
+	// the command name may contain spaces, fields are counted after its closing parenthesis
+	end := strings.LastIndexByte(data, ')')
+	if end < 0 {
+		return 0, errors.New("malformed stat")
+	}
+	fields := strings.Fields(data[end+1:])
+	if len(fields) < 13 {
+		return 0, errors.New("malformed stat")
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid utime: %v", err)
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid stime: %v", err)
+	}
+	return utime + stime, nil
+}
+
+// readRunnable returns the number of currently runnable tasks from loadavg.
+func readRunnable(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return parseRunnable(string(data))
+}
+
+func parseRunnable(data string) (int, error) {
+	fields := strings.Fields(data)
+	if len(fields) < 4 {
+		return 0, errors.New("malformed loadavg")
+	}
+	running, _, ok := strings.Cut(fields[3], "/")
+	if !ok {
+		return 0, errors.New("malformed loadavg")
+	}
+	return strconv.Atoi(running)
+}
+
+// readInputIdle reports how long the user has been away from the keyboard,
+// using xprintidle on graphical sessions and terminal access times otherwise.
+func readInputIdle() time.Duration {
+	if path, err := exec.LookPath("xprintidle"); err == nil {
+		out, err := exec.Command(path).Output()
+		if err == nil {
+			if ms, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64); err == nil {
+				return time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+
+	return ttyInputIdle()
+}
+
+func formatInputIdle(d time.Duration) string {
+	if d < 0 {
+		return "n/a"
+	}
+	return d.Truncate(time.Second).String()
+}
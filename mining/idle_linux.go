@@ -0,0 +1,38 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package mining
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// ttyInputIdle derives input idle time from the last access of the pseudo
+// terminals, the same way w(1) does.
+func ttyInputIdle() time.Duration {
+	ttys, _ := filepath.Glob("/dev/pts/[0-9]*")
+
+	var latest time.Time
+	for _, tty := range ttys {
+		info, err := os.Stat(tty)
+		if err != nil {
+			continue
+		}
+		st, ok := info.Sys().(*syscall.Stat_t)
+		if !ok {
+			continue
+		}
+		if atime := time.Unix(st.Atim.Unix()); atime.After(latest) {
+			latest = atime
+		}
+	}
+
+	if latest.IsZero() {
+		return -1
+	}
+	return time.Since(latest)
+}
@@ -0,0 +1,13 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+//go:build !linux
+
+package mining
+
+import "time"
+
+func ttyInputIdle() time.Duration {
+	return -1
+}
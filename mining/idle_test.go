@@ -0,0 +1,80 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package mining
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flokiorg/grpc-miner/common"
+	"github.com/rs/zerolog/log"
+)
+
+func TestParseProcFiles(t *testing.T) {
+	total, idle, err := parseProcStat("cpu  14057 0 4114 24424 3557 0 10 85 0 0\ncpu0 7000 0 2000 12000 1700 0 5 40 0 0\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 46247 || idle != 27981 {
+		t.Fatalf("unexpected cpu counters, total=%d idle=%d", total, idle)
+	}
+
+	self, err := parseSelfStat("6277 (g miner) R 6271 6277 6271 0 -1 4194304 83 0 0 0 120 30 0 0 20 0 1 0 46617")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if self != 150 {
+		t.Fatalf("unexpected self jiffies, want=150 got=%d", self)
+	}
+
+	runnable, err := parseRunnable("0.06 0.31 0.18 3/74 6275\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runnable != 3 {
+		t.Fatalf("unexpected runnable count, want=3 got=%d", runnable)
+	}
+}
+
+func TestIdleHysteresis(t *testing.T) {
+	cfg := &common.Config{
+		IdleCPUHigh:     0.5,
+		IdleCPULow:      0.1,
+		IdleRunnable:    0.5,
+		IdleInputTime:   time.Minute,
+		IdleResumeDelay: time.Second * 30,
+	}
+
+	miner := NewMiner(cfg, nil, nil, log.Logger)
+	im := NewIdleMonitor(cfg, miner, log.Logger)
+
+	now := time.Now()
+	steps := []struct {
+		name   string
+		after  time.Duration
+		sample idleSample
+		paused bool
+	}{
+		{"idle machine keeps mining", 0, idleSample{otherCPU: 0.05, inputIdle: -1}, false},
+		{"busy machine pauses", time.Second, idleSample{otherCPU: 0.8, inputIdle: -1}, true},
+		{"between watermarks stays paused", time.Minute, idleSample{otherCPU: 0.3, inputIdle: -1}, true},
+		{"below low watermark waits", time.Second, idleSample{otherCPU: 0.05, inputIdle: -1}, true},
+		{"runnable tasks above their watermark reset the delay", time.Second * 29, idleSample{otherCPU: 0.05, otherRunnable: 0.75, inputIdle: -1}, true},
+		{"a few runnable tasks do not", time.Second, idleSample{otherCPU: 0.05, otherRunnable: 0.25, inputIdle: -1}, true},
+		{"still waiting", time.Second * 29, idleSample{otherCPU: 0.05, otherRunnable: 0.25, inputIdle: -1}, true},
+		{"resumes with a few runnable tasks", time.Second, idleSample{otherCPU: 0.05, otherRunnable: 0.25, inputIdle: -1}, false},
+		{"user input resets the delay", time.Second * 20, idleSample{otherCPU: 0.05, inputIdle: time.Second}, true},
+		{"idle again", time.Second, idleSample{otherCPU: 0.05, inputIdle: time.Hour}, true},
+		{"resumes after the delay", time.Second * 30, idleSample{otherCPU: 0.05, inputIdle: time.Hour}, false},
+	}
+
+	for _, step := range steps {
+		now = now.Add(step.after)
+		im.step(step.sample, now)
+		if miner.Paused() != step.paused {
+			t.Fatalf("%s: unexpected pause state, want=%v got=%v", step.name, step.paused, miner.Paused())
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package mining
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/flokiorg/grpc-miner/hash/sha256"
+	. "github.com/flokiorg/grpc-miner/mining/algo/common"
+	"github.com/flokiorg/grpc-miner/mining/pb"
+	"github.com/flokiorg/grpc-miner/utils"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	JournalPending  = "pending"
+	JournalAccepted = "accepted"
+	JournalRejected = "rejected"
+	JournalStale    = "stale"
+)
+
+// JournalEntry is one line of the journal. The first line of a solution
+// carries everything needed to resubmit it, later lines only update its status.
+type JournalEntry struct {
+	ID       string    `json:"id"`
+	Time     time.Time `json:"time"`
+	Status   string    `json:"status"`
+	Pool     string    `json:"pool,omitempty"`
+	Height   int64     `json:"height,omitempty"`
+	Header   string    `json:"header,omitempty"`
+	Nonce    uint32    `json:"nonce,omitempty"`
+	Template []byte    `json:"template,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Journal is an append-only log of found solutions, written before they are
+// submitted so that a crash or an unreachable pool never loses a block.
+type Journal struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func OpenJournal(path string) (*Journal, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+
+	j := &Journal{file: file}
+	if err := j.repair(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return j, nil
+}
+
+// repair ends a torn line so the next entry starts on its own.
+func (j *Journal) repair() error {
+	info, err := j.file.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+	last := make([]byte, 1)
+	if _, err := j.file.ReadAt(last, info.Size()-1); err != nil {
+		return fmt.Errorf("failed to read journal: %w", err)
+	}
+	if last[0] != '\n' {
+		if _, err := j.file.Write([]byte{'\n'}); err != nil {
+			return fmt.Errorf("failed to write journal: %w", err)
+		}
+	}
+	return nil
+}
+
+// Record persists a found solution as pending and returns its id, the hash of the solved block.
+func (j *Journal) Record(pool string, block *pb.CandidateBlock, nonce uint32) (string, error) {
+	if j == nil {
+		return "", nil
+	}
+
+	header, err := solvedHeader(block, nonce)
+	if err != nil {
+		return "", err
+	}
+	template, err := proto.Marshal(block)
+	if err != nil {
+		return "", err
+	}
+
+	entry := JournalEntry{
+		ID:       blockHash(header),
+		Time:     time.Now(),
+		Status:   JournalPending,
+		Pool:     pool,
+		Height:   block.Height,
+		Header:   hex.EncodeToString(header),
+		Nonce:    nonce,
+		Template: template,
+	}
+	return entry.ID, j.append(entry)
+}
+
+// Mark records the outcome of a submission.
+func (j *Journal) Mark(id string, status string, cause error) error {
+	if j == nil || id == "" {
+		return nil
+	}
+
+	entry := JournalEntry{
+		ID:     id,
+		Time:   time.Now(),
+		Status: status,
+	}
+	if cause != nil {
+		entry.Error = cause.Error()
+	}
+	return j.append(entry)
+}
+
+func (j *Journal) append(entry JournalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return j.file.Sync()
+}
+
+// Pending returns the solutions whose latest status is still pending, in the order they were found.
+func (j *Journal) Pending() ([]JournalEntry, error) {
+	if j == nil {
+		return nil, nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, err := j.file.Seek(0, 0); err != nil {
+		return nil, err
+	}
+
+	var (
+		order   []string
+		entries = make(map[string]*JournalEntry)
+	)
+
+	scanner := bufio.NewScanner(j.file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// a torn last line from a crash mid-write
+			continue
+		}
+
+		if known, ok := entries[entry.ID]; ok {
+			known.Status = entry.Status
+			known.Error = entry.Error
+			continue
+		}
+		if entry.Status != JournalPending || entry.Template == nil {
+			continue
+		}
+		entries[entry.ID] = &entry
+		order = append(order, entry.ID)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var pending []JournalEntry
+	for _, id := range order {
+		if entries[id].Status == JournalPending {
+			pending = append(pending, *entries[id])
+		}
+	}
+	return pending, nil
+}
+
+func (j *Journal) Close() error {
+	if j == nil {
+		return nil
+	}
+	return j.file.Close()
+}
+
+// Block restores the template a journaled solution was found on.
+func (e *JournalEntry) Block() (*pb.CandidateBlock, error) {
+	block := &pb.CandidateBlock{}
+	if err := proto.Unmarshal(e.Template, block); err != nil {
+		return nil, fmt.Errorf("corrupted journal template %s: %w", e.ID, err)
+	}
+	return block, nil
+}
+
+// solvedHeader returns the serialized template header with the nonce filled in.
+func solvedHeader(block *pb.CandidateBlock, nonce uint32) ([]byte, error) {
+	if len(block.Header) < BLOCK_NONCELESS_LENGTH {
+		return nil, fmt.Errorf("template header too short: %d", len(block.Header))
+	}
+	header, err := hex.DecodeString(block.Header[:BLOCK_NONCELESS_LENGTH])
+	if err != nil {
+		return nil, fmt.Errorf("invalid template header: %w", err)
+	}
+	return binary.LittleEndian.AppendUint32(header, nonce), nil
+}
+
+// blockHash returns the displayed (byte reversed) double sha256 of a serialized header.
+func blockHash(header []byte) string {
+	hash := sha256.DoubleSum256(header)
+	utils.ReverseBytes(hash)
+	return hex.EncodeToString(hash)
+}
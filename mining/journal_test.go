@@ -0,0 +1,218 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package mining
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/flokiorg/grpc-miner/common"
+	"github.com/rs/zerolog/log"
+)
+
+func TestJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gminer.journal")
+
+	journal, err := OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := createCandidateBlock(t, "207fffff")
+	ids := make([]string, 3)
+	for i := range ids {
+		if ids[i], err = journal.Record("localhost:9900", block, uint32(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := journal.Mark(ids[0], JournalAccepted, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Mark(ids[2], JournalRejected, errors.New("bad nonce")); err != nil {
+		t.Fatal(err)
+	}
+	journal.Close()
+
+	// simulate a crash in the middle of a write
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"id":"deadbeef","sta`)
+	file.Close()
+
+	journal, err = OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	// the solution found after the crash is not glued to the torn line
+	after, err := journal.Record("localhost:9900", block, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := journal.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 || pending[0].ID != ids[1] || pending[0].Nonce != 1 || pending[1].ID != after {
+		t.Fatalf("unexpected pending solutions: %+v", pending)
+	}
+
+	restored, err := pending[0].Block()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Header != block.Header || restored.Height != block.Height {
+		t.Fatalf("template not restored, got=%+v", restored)
+	}
+}
+
+func TestResubmitPending(t *testing.T) {
+	journal, err := OpenJournal(filepath.Join(t.TempDir(), "gminer.journal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	stale := createCandidateBlock(t, "207fffff")
+	stale.Height--
+	current := createCandidateBlock(t, "207fffff")
+
+	if _, err := journal.Record("localhost:9900", stale, 7); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := journal.Record("localhost:9900", current, 8); err != nil {
+		t.Fatal(err)
+	}
+	elsewhere, err := journal.Record("localhost:9901", current, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	miner := NewMiner(&common.Config{PoolServer: "localhost:9900", MineOnce: true}, nil, nil, log.Logger)
+	miner.journal = journal
+	miner.resubmitPending(&clientMockSuccess{}, current)
+	miner.submits.Wait()
+
+	if miner.acceptedBlocks != 1 {
+		t.Fatalf("unexpected accepted blocks, want=1 got=%d", miner.acceptedBlocks)
+	}
+
+	// the solution for another pool waits for that pool
+	pending, err := journal.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].ID != elsewhere {
+		t.Fatalf("expected only the solution for another pool left, got=%+v", pending)
+	}
+
+	miner = NewMiner(&common.Config{PoolServer: "localhost:9901", MineOnce: true}, nil, nil, log.Logger)
+	miner.journal = journal
+	miner.resubmitPending(&clientMockSuccess{}, current)
+	miner.submits.Wait()
+
+	if status := journalStatus(t, journal, elsewhere); status != JournalAccepted {
+		t.Fatalf("unexpected status of the solution once its pool is back: %s", status)
+	}
+}
+
+func TestResubmitPendingAlreadyAccepted(t *testing.T) {
+	journal, err := OpenJournal(filepath.Join(t.TempDir(), "gminer.journal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	block := createCandidateBlock(t, "207fffff")
+	id, err := journal.Record("localhost:9900", block, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the pool took the solution, only its ack was lost
+	miner := NewMiner(&common.Config{PoolServer: "localhost:9900", MineOnce: true}, nil, nil, log.Logger)
+	miner.journal = journal
+	miner.resubmitPending(&clientMockDuplicate{}, block)
+	miner.submits.Wait()
+
+	if miner.acceptedBlocks != 1 {
+		t.Fatalf("unexpected accepted blocks, want=1 got=%d", miner.acceptedBlocks)
+	}
+	if status := journalStatus(t, journal, id); status != JournalAccepted {
+		t.Fatalf("unexpected status of the replayed solution: %s", status)
+	}
+}
+
+// redirectedClient is connected to another pool than the configured one.
+type redirectedClient struct {
+	clientMockSuccess
+	address string
+}
+
+func (rc *redirectedClient) Address() string {
+	return rc.address
+}
+
+func TestSubmitRecordsConnectedPool(t *testing.T) {
+	dir := t.TempDir()
+	journal, err := OpenJournal(filepath.Join(dir, "gminer.journal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+	ledger, err := OpenLedger(filepath.Join(dir, "gminer.ledger"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ledger.Close()
+
+	miner := NewMiner(&common.Config{PoolServer: "localhost:9900", MineOnce: true}, nil, nil, log.Logger)
+	miner.journal, miner.ledger = journal, ledger
+	miner.submits.Add(1)
+	miner.submit(&redirectedClient{address: "localhost:9901"}, createCandidateBlock(t, "207fffff"), 8)
+
+	data, err := os.ReadFile(journal.file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var recorded JournalEntry
+	if err := json.Unmarshal([]byte(strings.SplitN(string(data), "\n", 2)[0]), &recorded); err != nil {
+		t.Fatal(err)
+	}
+	if recorded.Pool != "localhost:9901" {
+		t.Fatalf("solution journaled for pool %s", recorded.Pool)
+	}
+	if entry, ok := ledger.Entry(recorded.ID); !ok || entry.Pool != "localhost:9901" {
+		t.Fatalf("unexpected ledger entry: %+v", entry)
+	}
+}
+
+// journalStatus returns the latest status of a solution in the journal file.
+func journalStatus(t *testing.T, journal *Journal, id string) string {
+	t.Helper()
+	data, err := os.ReadFile(journal.file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var status string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry JournalEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry.ID == id {
+			status = entry.Status
+		}
+	}
+	return status
+}
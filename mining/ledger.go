@@ -0,0 +1,261 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package mining
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/flokiorg/grpc-miner/mining/pb"
+)
+
+const (
+	LedgerSubmitted = "submitted"
+	LedgerConfirmed = "confirmed"
+	LedgerMatured   = "matured"
+	LedgerOrphaned  = "orphaned"
+)
+
+// LedgerEntry is one line of the ledger. The first line of a block records
+// what it was mined for, later lines only update where it stands in the chain.
+type LedgerEntry struct {
+	Hash          string    `json:"hash"`
+	Time          time.Time `json:"time"`
+	State         string    `json:"state"`
+	Pool          string    `json:"pool,omitempty"`
+	Height        int64     `json:"height,omitempty"`
+	Address       string    `json:"address,omitempty"`
+	Reward        int64     `json:"reward,omitempty"`
+	Confirmations int64     `json:"confirmations,omitempty"`
+}
+
+// Settled reports whether the block can no longer change state.
+func (e *LedgerEntry) Settled() bool {
+	return e.State == LedgerMatured || e.State == LedgerOrphaned
+}
+
+// LedgerSummary adds up the rewards of the blocks in the ledger by state.
+type LedgerSummary struct {
+	Blocks   int
+	Pending  int
+	Matured  int
+	Orphaned int
+
+	PendingReward int64
+	MaturedReward int64
+}
+
+// Ledger is an append-only log of the blocks accepted by the pool and of
+// where they stand in the chain, kept apart from the journal which forgets
+// about a block once it is submitted.
+type Ledger struct {
+	mu      sync.Mutex
+	file    *os.File
+	order   []string
+	entries map[string]*LedgerEntry
+}
+
+func OpenLedger(path string) (*Ledger, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ledger: %w", err)
+	}
+
+	l := &Ledger{file: file, entries: make(map[string]*LedgerEntry)}
+	if err := l.load(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Ledger) load() error {
+	scanner := bufio.NewScanner(l.file)
+	for scanner.Scan() {
+		var entry LedgerEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// a torn last line from a crash mid-write
+			continue
+		}
+		l.apply(entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read ledger: %w", err)
+	}
+
+	// end a torn line so the next entry starts on its own
+	info, err := l.file.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+	last := make([]byte, 1)
+	if _, err := l.file.ReadAt(last, info.Size()-1); err != nil {
+		return fmt.Errorf("failed to read ledger: %w", err)
+	}
+	if last[0] != '\n' {
+		if _, err := l.file.Write([]byte{'\n'}); err != nil {
+			return fmt.Errorf("failed to write ledger: %w", err)
+		}
+	}
+	return nil
+}
+
+func (l *Ledger) apply(entry LedgerEntry) {
+	known, ok := l.entries[entry.Hash]
+	if !ok {
+		l.entries[entry.Hash] = &entry
+		l.order = append(l.order, entry.Hash)
+		return
+	}
+	known.Time = entry.Time
+	known.State = entry.State
+	known.Confirmations = entry.Confirmations
+	if entry.Height != 0 {
+		known.Height = entry.Height
+	}
+}
+
+// Add records a block the pool accepted, hash being its displayed hash.
+func (l *Ledger) Add(pool, hash string, block *pb.CandidateBlock) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.entries[hash]; ok {
+		return nil
+	}
+	return l.append(LedgerEntry{
+		Hash:    hash,
+		Time:    time.Now(),
+		State:   LedgerSubmitted,
+		Pool:    pool,
+		Height:  block.Height,
+		Address: block.Address,
+		Reward:  block.Amount,
+	})
+}
+
+// Update records where a block stands, it reports whether its state changed.
+func (l *Ledger) Update(info *pb.BlockInfo) (bool, error) {
+	if l == nil {
+		return false, nil
+	}
+
+	state := ledgerState(info.State)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	known, ok := l.entries[info.Hash]
+	if !ok || state == "" || (known.State == state && known.Confirmations == info.Confirmations) {
+		return false, nil
+	}
+	changed := known.State != state
+	return changed, l.append(LedgerEntry{
+		Hash:          info.Hash,
+		Time:          time.Now(),
+		State:         state,
+		Height:        info.Height,
+		Confirmations: info.Confirmations,
+	})
+}
+
+// ledgerState maps a pool reported state to the ledger's, a block unknown to
+// the node keeps the state it had.
+func ledgerState(state pb.BlockState) string {
+	switch state {
+	case pb.BlockState_BLOCK_CONFIRMED:
+		return LedgerConfirmed
+	case pb.BlockState_BLOCK_MATURED:
+		return LedgerMatured
+	case pb.BlockState_BLOCK_ORPHANED:
+		return LedgerOrphaned
+	}
+	return ""
+}
+
+func (l *Ledger) append(entry LedgerEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write ledger: %w", err)
+	}
+	l.apply(entry)
+	return l.file.Sync()
+}
+
+// Entry returns the latest state of a block.
+func (l *Ledger) Entry(hash string) (LedgerEntry, bool) {
+	if l == nil {
+		return LedgerEntry{}, false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[hash]
+	if !ok {
+		return LedgerEntry{}, false
+	}
+	return *entry, true
+}
+
+// Unsettled returns the blocks that may still change state, in the order they were found.
+func (l *Ledger) Unsettled() []LedgerEntry {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var unsettled []LedgerEntry
+	for _, hash := range l.order {
+		if entry := l.entries[hash]; !entry.Settled() {
+			unsettled = append(unsettled, *entry)
+		}
+	}
+	return unsettled
+}
+
+func (l *Ledger) Summary() LedgerSummary {
+	var summary LedgerSummary
+	if l == nil {
+		return summary
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, entry := range l.entries {
+		summary.Blocks++
+		switch entry.State {
+		case LedgerMatured:
+			summary.Matured++
+			summary.MaturedReward += entry.Reward
+		case LedgerOrphaned:
+			summary.Orphaned++
+		default:
+			summary.Pending++
+			summary.PendingReward += entry.Reward
+		}
+	}
+	return summary
+}
+
+func (l *Ledger) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.file.Close()
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package mining
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/flokiorg/grpc-miner/mining/pb"
+	"github.com/flokiorg/grpc-miner/pool"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+)
+
+func TestLedger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gminer.ledger")
+
+	ledger, err := OpenLedger(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := &pb.CandidateBlock{Height: 100, Amount: 5000, Address: "addr"}
+	hashes := []string{strings.Repeat("a", 64), strings.Repeat("b", 64), strings.Repeat("c", 64)}
+	for _, hash := range hashes {
+		if err := ledger.Add("localhost:9900", hash, block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a block is recorded once however many times it is accepted
+	if err := ledger.Add("localhost:9900", hashes[0], block); err != nil {
+		t.Fatal(err)
+	}
+
+	updates := []struct {
+		info    *pb.BlockInfo
+		changed bool
+	}{
+		{&pb.BlockInfo{Hash: hashes[0], State: pb.BlockState_BLOCK_CONFIRMED, Height: 100, Confirmations: 1}, true},
+		{&pb.BlockInfo{Hash: hashes[0], State: pb.BlockState_BLOCK_CONFIRMED, Height: 100, Confirmations: 2}, false},
+		{&pb.BlockInfo{Hash: hashes[0], State: pb.BlockState_BLOCK_MATURED, Height: 100, Confirmations: 101}, true},
+		{&pb.BlockInfo{Hash: hashes[1], State: pb.BlockState_BLOCK_ORPHANED, Height: 100}, true},
+		{&pb.BlockInfo{Hash: hashes[2], State: pb.BlockState_BLOCK_UNKNOWN}, false},
+		{&pb.BlockInfo{Hash: strings.Repeat("d", 64), State: pb.BlockState_BLOCK_CONFIRMED}, false},
+	}
+	for i, update := range updates {
+		changed, err := ledger.Update(update.info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed != update.changed {
+			t.Fatalf("update %d: changed=%v, want %v", i, changed, update.changed)
+		}
+	}
+	ledger.Close()
+
+	// simulate a crash in the middle of a write
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"hash":"deadbeef","sta`)
+	file.Close()
+
+	ledger, err = OpenLedger(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ledger.Update(&pb.BlockInfo{Hash: hashes[2], State: pb.BlockState_BLOCK_CONFIRMED, Height: 100, Confirmations: 1}); err != nil {
+		t.Fatal(err)
+	}
+	ledger.Close()
+
+	ledger, err = OpenLedger(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ledger.Close()
+
+	entry, ok := ledger.Entry(hashes[0])
+	if !ok || entry.State != LedgerMatured || entry.Confirmations != 101 || entry.Reward != 5000 || entry.Pool != "localhost:9900" {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+	unsettled := ledger.Unsettled()
+	if len(unsettled) != 1 || unsettled[0].Hash != hashes[2] || unsettled[0].State != LedgerConfirmed {
+		t.Fatalf("unexpected unsettled blocks: %+v", unsettled)
+	}
+
+	want := LedgerSummary{Blocks: 3, Pending: 1, Matured: 1, Orphaned: 1, PendingReward: 5000, MaturedReward: 5000}
+	if summary := ledger.Summary(); summary != want {
+		t.Fatalf("summary=%+v, want %+v", summary, want)
+	}
+}
+
+func TestCheckBlocks(t *testing.T) {
+	backend := pool.NewMemoryBackend()
+	addr := startPool(t, func(r grpc.ServiceRegistrar) { pool.NewServer(backend).Register(r) })
+
+	cfg := testConfig(addr)
+	cfg.LedgerFile = filepath.Join(t.TempDir(), "gminer.ledger")
+	miner := NewMiner(cfg, nil, &pb.CandidateRequest{}, log.Logger)
+
+	client, err := NewClient(cfg, miner.hello())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if miner.ledger, err = OpenLedger(cfg.LedgerFile); err != nil {
+		t.Fatal(err)
+	}
+	defer miner.ledger.Close()
+
+	confirmed, orphaned := strings.Repeat("a", 64), strings.Repeat("b", 64)
+	for _, hash := range []string{confirmed, orphaned} {
+		miner.ledger.Add(addr, hash, &pb.CandidateBlock{Height: 100, Amount: 5000})
+	}
+	backend.SetBlockStatus(&pb.BlockInfo{Hash: confirmed, State: pb.BlockState_BLOCK_CONFIRMED, Height: 100, Confirmations: 3})
+	backend.SetBlockStatus(&pb.BlockInfo{Hash: orphaned, State: pb.BlockState_BLOCK_ORPHANED, Height: 100})
+
+	if err := miner.checkBlocks(context.Background(), client); err != nil {
+		t.Fatal(err)
+	}
+	if entry, _ := miner.ledger.Entry(confirmed); entry.State != LedgerConfirmed || entry.Confirmations != 3 {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+	if entry, _ := miner.ledger.Entry(orphaned); entry.State != LedgerOrphaned {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+
+	backend.SetBlockStatus(&pb.BlockInfo{Hash: confirmed, State: pb.BlockState_BLOCK_MATURED, Height: 100, Confirmations: 101})
+	if err := miner.checkBlocks(context.Background(), client); err != nil {
+		t.Fatal(err)
+	}
+	if unsettled := miner.ledger.Unsettled(); len(unsettled) != 0 {
+		t.Fatalf("blocks left unsettled: %+v", unsettled)
+	}
+}
+
+func TestCheckBlocksUnsupported(t *testing.T) {
+	backend := pool.NewMemoryBackend()
+	addr := startPool(t, func(r grpc.ServiceRegistrar) { pool.NewServer(backend).Register(r) })
+
+	// without a ledger the miner does not ask for block status
+	cfg := testConfig(addr)
+	client, err := NewClient(cfg, NewMiner(cfg, nil, &pb.CandidateRequest{}, log.Logger).hello())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if _, err := client.BlockStatus(context.Background(), []string{strings.Repeat("a", 64)}); err != ErrBlockStatusUnsupported {
+		t.Fatalf("expected ErrBlockStatusUnsupported, got %v", err)
+	}
+}
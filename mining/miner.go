@@ -5,24 +5,33 @@
 package mining
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"iter"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/flokiorg/grpc-miner/common"
-	"github.com/flokiorg/grpc-miner/hash/sha256"
 	"github.com/flokiorg/grpc-miner/mining/algo"
 	. "github.com/flokiorg/grpc-miner/mining/algo/common"
+	"github.com/flokiorg/grpc-miner/mining/compress"
 	"github.com/flokiorg/grpc-miner/mining/pb"
 	"github.com/flokiorg/grpc-miner/utils"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
+const pauseReasonSlowDown = "slowdown"
+
+var ErrSubmissionAbandoned = errors.New("pending submission abandoned on shutdown")
+
 type Miner struct {
 	candidateRequest *pb.CandidateRequest
 	ma               algo.MinerAlgo
@@ -30,10 +39,29 @@ type Miner struct {
 	stats            *Stats
 	mu               sync.Mutex
 	logger           zerolog.Logger
+	clock            utils.Clock
+	dial             ClientFactory
+	events           *Bus
 
 	acceptedBlocks uint32
 	cancel         context.CancelFunc
 	wg             sync.WaitGroup
+	solved         atomic.Pointer[pb.CandidateBlock]
+	jobDone        chan *pb.CandidateBlock
+	handover       atomic.Bool
+
+	payouts       *XpubPayouts
+	payoutRotated chan struct{}
+
+	submits   sync.WaitGroup
+	submitCtx context.Context
+	abandon   context.CancelFunc
+	journal   *Journal
+	ledger    *Ledger
+
+	pauseMu sync.Mutex
+	pauses  map[string]struct{}
+	pauseCh chan struct{}
 }
 
 type workers struct {
@@ -42,6 +70,7 @@ type workers struct {
 	nonceRanges map[uint8]utils.MinMax
 	algo        algo.MinerAlgo
 	stats       *Stats
+	handover    *atomic.Bool
 	mu          sync.Mutex
 
 	cancel    context.CancelFunc
@@ -58,27 +87,127 @@ func (w *workers) run(ctx context.Context, tid uint8, logger zerolog.Logger) {
 	nonceRange := w.nonceRanges[tid]
 	log.Debug().Msgf("b[%d] t[%d] nonce.range=(%d, %d)", w.block.Height, tid, nonceRange.Min, nonceRange.Max)
 
-	blockhash, nonce, err := w.algo.Mine(ctx, w.stats, w.block, nonceRange, tid)
-	if err == nil {
-		w.mu.Lock()
-		w.blockhash = blockhash
-		w.nonce = nonce
-		w.mu.Unlock()
-		w.cancel()
-		return
+	for chunk := range nonceChunks(nonceRange, NONCE_CHUNK) {
+		blockhash, nonce, err := w.algo.Mine(ctx, w.stats, w.block, chunk, tid)
+		if err == nil {
+			w.mu.Lock()
+			w.blockhash = blockhash
+			w.nonce = nonce
+			w.mu.Unlock()
+			w.cancel()
+			return
+		}
+		if !errors.Is(err, ErrMiningCompleted) {
+			if !errors.Is(err, ErrMiningCancelled) {
+				logger.Error().Err(err).Msg("mining failed")
+			}
+			return
+		}
+		if w.handover.Load() {
+			log.Debug().Msgf("b[%d] t[%d] handing over to the queued job at nonce %d", w.block.Height, tid, chunk.Max)
+			return
+		}
 	}
-	if !errors.Is(err, ErrMiningCancelled) && !errors.Is(err, ErrMiningCompleted) {
-		logger.Error().Err(err).Msg("mining failed")
+}
+
+// nonceChunks splits r into ranges of size nonces, the last one may be shorter.
+func nonceChunks(r utils.MinMax, size uint32) iter.Seq[utils.MinMax] {
+	return func(yield func(utils.MinMax) bool) {
+		for min := r.Min; ; min += size {
+			chunk := utils.MinMax{Min: min, Max: r.Max}
+			if r.Max-min >= size {
+				chunk.Max = min + size - 1
+			}
+			if !yield(chunk) || chunk.Max == r.Max {
+				return
+			}
+		}
 	}
 }
 
 func NewMiner(cfg *common.Config, ma algo.MinerAlgo, request *pb.CandidateRequest, logger zerolog.Logger) *Miner {
-	return &Miner{
+	submitCtx, abandon := context.WithCancel(context.Background())
+	m := &Miner{
 		cfg:              cfg,
 		ma:               ma,
 		stats:            NewStats(),
 		logger:           logger,
+		clock:            utils.RealClock,
+		dial:             DialClient,
+		events:           NewBus(),
 		candidateRequest: request,
+		pauses:           make(map[string]struct{}),
+		pauseCh:          make(chan struct{}, 1),
+		jobDone:          make(chan *pb.CandidateBlock, 1),
+		payoutRotated:    make(chan struct{}, 1),
+		submitCtx:        submitCtx,
+		abandon:          abandon,
+	}
+	// logs are kept from the events like any other subscriber would
+	m.events.Subscribe(m.logEvent)
+	return m
+}
+
+// Events returns the bus the miner and its pool client publish to.
+func (m *Miner) Events() *Bus {
+	return m.events
+}
+
+// SetClock replaces the clock timing siestas, slowdowns and the shutdown
+// timeout, it must be called before Run.
+func (m *Miner) SetClock(clock utils.Clock) {
+	m.clock = clock
+}
+
+// SetClientFactory replaces how the miner connects to its pool, gRPC by
+// default. It must be called before Run or Generate.
+func (m *Miner) SetClientFactory(factory ClientFactory) {
+	m.dial = factory
+}
+
+// Pause stops the workers until every reason it was paused for is resumed.
+// The pool stream stays open meanwhile, so new templates keep flowing in.
+func (m *Miner) Pause(reason string) {
+	m.pauseMu.Lock()
+	defer m.pauseMu.Unlock()
+
+	if _, ok := m.pauses[reason]; ok {
+		return
+	}
+	m.pauses[reason] = struct{}{}
+	m.logger.Info().Msgf("⏸️ mining paused (%s)", reason)
+	m.notifyPause()
+}
+
+func (m *Miner) Resume(reason string) {
+	m.pauseMu.Lock()
+	defer m.pauseMu.Unlock()
+
+	if _, ok := m.pauses[reason]; !ok {
+		return
+	}
+	delete(m.pauses, reason)
+	if len(m.pauses) == 0 {
+		m.logger.Info().Msgf("▶️ mining resumed (%s)", reason)
+	}
+	m.notifyPause()
+}
+
+// Accepted returns how many blocks the pool accepted.
+func (m *Miner) Accepted() uint32 {
+	return atomic.LoadUint32(&m.acceptedBlocks)
+}
+
+func (m *Miner) Paused() bool {
+	m.pauseMu.Lock()
+	defer m.pauseMu.Unlock()
+	return len(m.pauses) > 0
+}
+
+func (m *Miner) notifyPause() {
+	select {
+	case m.pauseCh <- struct{}{}:
+	default:
 	}
 }
 
@@ -86,15 +215,7 @@ func (m *Miner) processCandidate(parent context.Context, client ClientService, b
 	defer m.wg.Done()
 
 	m.stats.Reset()
-
-	lenDifficulty, _ := utils.CalcDifficulty(block.Bits)
-
-	m.logger.Info().Msgf("🌱 new block height:%d", block.Height)
-	m.logger.Info().Msgf("processing block: %d amount: %d txs: %d", block.Height, block.Amount, block.Transactions)
-	m.logger.Info().Msgf("version: %d", block.Version)
-	m.logger.Info().Msgf("target difficulty: %s/%d", block.Bits, lenDifficulty)
-	m.logger.Info().Msgf("merkleroot: %s", block.Merkleroot)
-	m.logger.Info().Msgf("address: %s", block.Address)
+	m.publish(MiningStarted{Time: m.clock.Now(), Block: block, Threads: m.cfg.Threads})
 
 	ctx, cancel := context.WithCancel(parent)
 
@@ -104,6 +225,7 @@ func (m *Miner) processCandidate(parent context.Context, client ClientService, b
 		block:       block,
 		algo:        m.ma,
 		stats:       m.stats,
+		handover:    &m.handover,
 		nonceRanges: utils.CalculateNonceRanges(TOTAL_NONCES, START_NONCE, m.cfg.Threads),
 	}
 
@@ -131,29 +253,234 @@ func (m *Miner) processCandidate(parent context.Context, client ClientService, b
 
 	workers.wg.Wait()
 
-	if len(workers.blockhash) > 0 {
-		m.logger.Info().Msgf("b[%d] ✨ nonce:%d", block.Height, workers.nonce)
-		m.logger.Info().Msgf("b[%d] ✨ solved hash:%s", block.Height, workers.blockhash)
+	solved := len(workers.blockhash) > 0
+	if solved {
+		// before Run hears the job is done, so that it does not pick it up again
+		m.solved.Store(block)
+	}
 
-		ack, err := client.SubmitNonce(parent, block, workers.nonce, m.cfg.MaxRetries, m.cfg.MaxBackoffSeconds)
-		if err != nil {
-			m.logger.Error().Err(err).Msgf("b[%d] ❌ failed submiting block.", block.Height)
+	// let Run move on to a queued job unless this one was stopped
+	if parent.Err() == nil {
+		select {
+		case m.jobDone <- block:
+		default:
+		}
+	}
+
+	if solved {
+		m.publish(SolutionFound{Time: m.clock.Now(), Block: block, Nonce: workers.nonce, Hash: workers.blockhash})
+
+		// the submission outlives the job, so neither a new template nor a
+		// shutdown request can drop a found block
+		m.submits.Add(1)
+		go m.submit(client, block, workers.nonce)
+	}
+}
+
+func (m *Miner) submit(client ClientService, block *pb.CandidateBlock, nonce uint32) {
+	defer m.submits.Done()
+
+	id, err := m.journal.Record(m.poolAddress(client), block, nonce)
+	if err != nil {
+		m.logger.Error().Err(err).Msgf("b[%d] failed to journal solution", block.Height)
+	}
+
+	m.deliver(client, id, block, nonce, false)
+	m.rotatePayout(block)
+}
+
+// rotatePayout moves to a fresh xpub address once the current one may have
+// received a block, whether or not the pool ends up accepting it. It runs once
+// the submission is resolved, renewing the request does not wait on it.
+func (m *Miner) rotatePayout(block *pb.CandidateBlock) {
+	if m.payouts == nil {
+		return
+	}
+	address, err := m.payouts.Rotate()
+	if err != nil {
+		m.logger.Error().Err(err).Msgf("b[%d] failed to rotate the payout address, keeping the current one", block.Height)
+		return
+	}
+	m.logger.Info().Msgf("💸 next blocks pay %s (index %d)", address, m.payouts.Index())
+
+	select {
+	case m.payoutRotated <- struct{}{}:
+	default:
+	}
+}
+
+// deliver submits a solution and records the outcome. A replayed solution the
+// pool already has was accepted before the miner heard back.
+func (m *Miner) deliver(client ClientService, id string, block *pb.CandidateBlock, nonce uint32, replay bool) {
+	ack, err := client.SubmitNonce(m.submitCtx, block, nonce, m.cfg.MaxRetries, m.cfg.MaxBackoffSeconds)
+	if replay && status.Code(err) == codes.AlreadyExists {
+		ack, err = acceptedAck(block, nonce)
+	}
+	if err != nil {
+		rejected := SubmitRejected{Time: m.clock.Now(), Block: block, Nonce: nonce, Err: err}
+		switch {
+		case rejected.Stale():
+			m.markJournal(id, JournalStale, nil)
+		case isTransientError(err):
+			rejected.Retry = true
+		default:
+			m.markJournal(id, JournalRejected, err)
+		}
+		m.publish(rejected)
+		return
+	}
+	m.markJournal(id, JournalAccepted, nil)
+
+	headerBytes, _ := hex.DecodeString(ack.Header)
+	hash := blockHash(headerBytes)
+	if err := m.ledger.Add(m.poolAddress(client), hash, block); err != nil {
+		m.logger.Error().Err(err).Msgf("b[%d] failed to record block %s in the ledger", block.Height, hash)
+	}
+	m.publish(SubmitAccepted{Time: m.clock.Now(), Block: block, Nonce: nonce, Hash: hash})
+
+	if !m.cfg.MineOnce && m.cfg.SlowDownDuration > 0 {
+		m.logger.Info().Msgf("🚦 slow down mining for %d secs", int(m.cfg.SlowDownDuration.Seconds()))
+		m.Pause(pauseReasonSlowDown)
+		go func() {
+			<-m.clock.After(m.cfg.SlowDownDuration)
+			m.Resume(pauseReasonSlowDown)
+		}()
+	}
+}
+
+// poolAddress is the pool server client is connected to, which differs from
+// the configured one once the pool redirected it.
+func (m *Miner) poolAddress(client ClientService) string {
+	if c, ok := client.(interface{ Address() string }); ok {
+		return c.Address()
+	}
+	return m.cfg.PoolServer
+}
+
+// publish counts the submission outcomes of event before handing it to the
+// subscribers, so that the stats never lag behind the submissions.
+func (m *Miner) publish(event Event) {
+	m.count(event)
+	m.events.Publish(event)
+}
+
+// count keeps the submission outcomes of the stats.
+func (m *Miner) count(event Event) {
+	switch e := event.(type) {
+	case SubmitAccepted:
+		atomic.AddUint32(&m.acceptedBlocks, 1)
+	case SubmitRejected:
+		if e.Stale() {
+			m.stats.Stale.Add(1)
+		} else if !e.Retry {
+			m.stats.Rejected.Add(1)
+		}
+	}
+}
+
+func (m *Miner) logEvent(event Event) {
+	switch e := event.(type) {
+	case TemplateReceived:
+		m.logger.Debug().Msgf("b[%d] 📥 template %s received", e.Block.Height, e.Block.JobId)
+
+	case MiningStarted:
+		block := e.Block
+		lenDifficulty, _ := utils.CalcDifficulty(block.Bits)
+		m.logger.Info().Msgf("🌱 new block height:%d", block.Height)
+		m.logger.Info().Msgf("processing block: %d amount: %d txs: %d", block.Height, block.Amount, block.Transactions)
+		m.logger.Info().Msgf("version: %d", block.Version)
+		m.logger.Info().Msgf("target difficulty: %s/%d", block.Bits, lenDifficulty)
+		m.logger.Info().Msgf("merkleroot: %s", block.Merkleroot)
+		m.logger.Info().Msgf("address: %s", block.Address)
+
+	case SolutionFound:
+		m.logger.Info().Msgf("b[%d] ✨ nonce:%d", e.Block.Height, e.Nonce)
+		m.logger.Info().Msgf("b[%d] ✨ solved hash:%s", e.Block.Height, e.Hash)
+
+	case SubmitAccepted:
+		m.logger.Info().Msgf("b[%d] ✨ block submited", e.Block.Height)
+		m.logger.Info().Msgf("b[%d] ✨ blockhash:%s", e.Block.Height, e.Hash)
+
+	case SubmitRejected:
+		switch {
+		case e.Stale():
+			m.logger.Warn().Msgf("b[%d] 🥀 solution went stale before it was accepted", e.Block.Height)
+		case e.Retry:
+			m.logger.Error().Err(e.Err).Msgf("b[%d] ❌ failed submiting block.", e.Block.Height)
+			m.logger.Warn().Msgf("b[%d] solution kept in journal for resubmission", e.Block.Height)
+		default:
+			m.logger.Error().Err(e.Err).Msgf("b[%d] ❌ failed submiting block.", e.Block.Height)
+		}
+
+	case StreamReconnected:
+		if e.Resumed {
+			m.logger.Info().Msgf("🔌 reconnected to %s, session resumed", e.Pool)
 		} else {
-			headerBytes, _ := hex.DecodeString(ack.Header)
-			blockhash := sha256.DoubleSum256(headerBytes)
-			utils.ReverseBytes(blockhash)
-			m.logger.Info().Msgf("b[%d] ✨ block submited", block.Height)
-			m.logger.Info().Msgf("b[%d] ✨ blockhash:%x", block.Height, blockhash)
+			m.logger.Info().Msgf("🔌 reconnected to %s", e.Pool)
+		}
 
-			atomic.AddUint32(&m.acceptedBlocks, 1)
+	case PoolSwitched:
+		m.logger.Warn().Msgf("🔀 switched from pool %s to %s", e.From, e.To)
 
-			if !m.cfg.MineOnce && m.cfg.SlowDownDuration > 0 {
-				m.logger.Info().Msgf("🚦 slow down mining for %d secs", int(m.cfg.SlowDownDuration.Seconds()))
-				time.Sleep(m.cfg.SlowDownDuration)
-			}
+	case HealthChanged:
+		event := m.logger.Warn()
+		if e.Status == pb.HealthStatus_SERVING {
+			event = m.logger.Info()
+		}
+		if e.Message != "" {
+			event = event.Str("message", e.Message)
 		}
+		if !e.Until.IsZero() {
+			event = event.Time("until", e.Until)
+		}
+		event.Msgf("🩺 pool %s is %s", e.Pool, e.Status)
+	}
+}
+
+func (m *Miner) markJournal(id string, status string, cause error) {
+	if err := m.journal.Mark(id, status, cause); err != nil {
+		m.logger.Error().Err(err).Msgf("failed to mark solution %s as %s", id, status)
 	}
+}
+
+// resubmitPending submits solutions left pending by a previous run, unless
+// the chain already moved past them.
+func (m *Miner) resubmitPending(client ClientService, tip *pb.CandidateBlock) {
+	pending, err := m.journal.Pending()
+	if err != nil {
+		m.logger.Error().Err(err).Msg("failed to read journal")
+		return
+	}
+
+	for _, entry := range pending {
+		block, err := entry.Block()
+		if err != nil {
+			m.logger.Error().Err(err).Msg("skipping journaled solution")
+			continue
+		}
+
+		if supersedes(tip, block) {
+			m.logger.Info().Msgf("b[%d] 📒 dropping journaled solution %s", entry.Height, entry.ID)
+			m.markJournal(entry.ID, JournalStale, nil)
+			m.publish(SubmitRejected{Time: m.clock.Now(), Block: block, Nonce: entry.Nonce, Err: ErrStaleSolution})
+			continue
+		}
+		if entry.Pool != m.poolAddress(client) {
+			// kept for when that pool is configured again, unless it goes stale first
+			m.logger.Warn().Msgf("b[%d] journaled solution %s belongs to pool %s, keeping it for that pool", entry.Height, entry.ID, entry.Pool)
+			continue
+		}
 
+		// the job the solution was found on is long gone from the pool
+		block.JobId = ""
+
+		m.logger.Info().Msgf("b[%d] 📒 resubmitting journaled solution %s", entry.Height, entry.ID)
+		m.submits.Add(1)
+		go func(id string, nonce uint32) {
+			defer m.submits.Done()
+			m.deliver(client, id, block, nonce, true)
+		}(entry.ID, entry.Nonce)
+	}
 }
 
 func (m *Miner) start(parent context.Context, client ClientService, block *pb.CandidateBlock) {
@@ -169,6 +496,7 @@ func (m *Miner) start(parent context.Context, client ClientService, block *pb.Ca
 	ctx, cancel := context.WithCancel(parent)
 	m.cancel = cancel
 	m.mu.Unlock()
+	m.handover.Store(false)
 
 	m.wg.Add(1)
 	go m.processCandidate(ctx, client, block)
@@ -186,46 +514,342 @@ func (m *Miner) stop() {
 
 }
 
-func (m *Miner) Run(ctx context.Context) {
+// shutdown stops the workers and waits up to ShutdownTimeout for pending
+// submissions before abandoning them.
+func (m *Miner) shutdown() error {
+	m.logger.Info().Msg("🛑 shutting down, stopping workers")
+	m.stop()
+
+	drained := make(chan struct{})
+	go func() {
+		m.submits.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+	default:
+		m.logger.Info().Msgf("⏳ waiting up to %s for pending submissions", m.cfg.ShutdownTimeout)
+		select {
+		case <-drained:
+		case <-m.clock.After(m.cfg.ShutdownTimeout):
+			m.logger.Error().Msg("❌ pending submissions did not complete in time, abandoning them")
+			m.abandon()
+			<-drained
+			err = ErrSubmissionAbandoned
+		}
+	}
+
+	m.events.Wait()
+	m.stats.PrintZeros()
+	m.logger.Info().Msgf("📊 accepted blocks: %d rejected: %d stale: %d", atomic.LoadUint32(&m.acceptedBlocks), m.stats.Rejected.Load(), m.stats.Stale.Load())
+	m.logLedger()
+	return err
+}
+
+func (m *Miner) logLedger() {
+	if m.ledger == nil {
+		return
+	}
+	summary := m.ledger.Summary()
+	m.logger.Info().Msgf("📒 found blocks: %d matured: %d (%d) pending: %d (%d) orphaned: %d",
+		summary.Blocks, summary.Matured, summary.MaturedReward, summary.Pending, summary.PendingReward, summary.Orphaned)
+}
+
+// trackBlocks follows the blocks of the ledger until they mature or are orphaned.
+func (m *Miner) trackBlocks(ctx context.Context, client PoolClient) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.clock.After(m.cfg.LedgerInterval):
+		}
+		if err := m.checkBlocks(ctx, client); errors.Is(err, ErrBlockStatusUnsupported) {
+			m.logger.Debug().Msg("pool does not report block status, not tracking blocks")
+			return
+		}
+	}
+}
+
+func (m *Miner) checkBlocks(ctx context.Context, client PoolClient) error {
+	unsettled := m.ledger.Unsettled()
+	if len(unsettled) == 0 {
+		return nil
+	}
+	hashes := make([]string, 0, len(unsettled))
+	for _, entry := range unsettled {
+		hashes = append(hashes, entry.Hash)
+	}
+
+	blocks, err := client.BlockStatus(ctx, hashes)
+	if err != nil {
+		if !errors.Is(err, ErrBlockStatusUnsupported) {
+			m.logger.Warn().Err(err).Msg("failed to check found blocks")
+		}
+		return err
+	}
+
+	for _, info := range blocks {
+		changed, err := m.ledger.Update(info)
+		if err != nil {
+			m.logger.Error().Err(err).Msgf("failed to update block %s in the ledger", info.Hash)
+			continue
+		}
+		if !changed {
+			continue
+		}
+		entry, _ := m.ledger.Entry(info.Hash)
+		switch entry.State {
+		case LedgerConfirmed:
+			m.logger.Info().Msgf("b[%d] ⛓️ block %s in the main chain (%d confirmations)", entry.Height, entry.Hash, entry.Confirmations)
+		case LedgerMatured:
+			m.logger.Info().Msgf("b[%d] 💰 block %s matured, reward %d is spendable", entry.Height, entry.Hash, entry.Reward)
+		case LedgerOrphaned:
+			m.logger.Warn().Msgf("b[%d] 🪦 block %s orphaned, reward %d lost", entry.Height, entry.Hash, entry.Reward)
+		}
+	}
+	return nil
+}
+
+// hello describes this miner to the pool during the handshake.
+func (m *Miner) hello() *pb.HelloRequest {
+	hello := &pb.HelloRequest{
+		ProtocolVersion: ProtocolVersion,
+		SoftwareVersion: utils.Version,
+		Features:        []pb.Feature{pb.Feature_FEATURE_SESSION},
+	}
+	if m.cfg.LedgerFile != "" {
+		hello.Features = append(hello.Features, pb.Feature_FEATURE_BLOCK_STATUS)
+	}
+	if !common.PlainCoinbaseText(m.candidateRequest.GetCoinbaseScript().GetText()) {
+		hello.Features = append(hello.Features, pb.Feature_FEATURE_COINBASE_TEXT)
+	}
+	if m.cfg.Algo != "" {
+		hello.Algorithms = []string{algo.PowAlgorithm(m.cfg.Algo)}
+	}
+	if compressors, _ := compress.Preference(m.cfg.Compression); len(compressors) > 0 {
+		hello.Features = append(hello.Features, pb.Feature_FEATURE_COMPRESSION)
+		hello.Compressors = compressors
+	}
+	return hello
+}
+
+func (m *Miner) Run(ctx context.Context) error {
 
-	client, err := NewClient(m.cfg.PoolServer, m.cfg.PoolTimeout)
+	client, err := m.dial(m.cfg, m.hello())
 	if err != nil {
-		log.Fatal().Err(err).Msgf("Failed to establish connection to the pool server at %s", m.cfg.PoolServer)
+		return fmt.Errorf("failed to establish connection to the pool server at %s: %w", m.cfg.PoolServer, err)
 	}
 	defer client.Close()
+	if source, ok := client.(EventSource); ok {
+		source.SetEvents(m.events)
+	}
+
+	// a pool that does not expand the text would put it in blocks verbatim
+	if text := m.candidateRequest.GetCoinbaseScript().GetText(); !common.PlainCoinbaseText(text) && !client.Supports(pb.Feature_FEATURE_COINBASE_TEXT) {
+		return fmt.Errorf("pool %s does not expand coinbase text variables and escapes, remove them from %q", m.cfg.PoolServer, text)
+	}
+
+	if m.cfg.JournalFile != "" {
+		if m.journal, err = OpenJournal(m.cfg.JournalFile); err != nil {
+			return err
+		}
+		defer m.journal.Close()
+	}
+
+	if m.cfg.LedgerFile != "" {
+		if m.ledger, err = OpenLedger(m.cfg.LedgerFile); err != nil {
+			return err
+		}
+		defer m.ledger.Close()
+		m.logLedger()
+
+		if m.cfg.LedgerInterval > 0 {
+			go m.trackBlocks(ctx, client)
+		}
+	}
+
+	request := m.candidateRequest
+	if m.cfg.Xpub != "" && len(request.MiningAddrs) == 0 {
+		if m.payouts, err = OpenXpubPayouts(m.cfg.Xpub, ChainParams(m.cfg.TestNet), m.cfg.XpubIndexFile); err != nil {
+			return err
+		}
+		request = withPayout(request, m.payouts.Address())
+		m.logger.Info().Msgf("💸 blocks pay %s (index %d)", m.payouts.Address(), m.payouts.Index())
+	}
 
 	blocks := make(chan *pb.CandidateBlock)
 
-	go client.Listen(ctx, m.candidateRequest, blocks)
+	go client.Listen(ctx, request, blocks)
 
-	var previousBlockHeight int64
+	if m.cfg.IdleMode {
+		go NewIdleMonitor(m.cfg, m, m.logger).Run(ctx)
+	}
+
+	if len(m.cfg.Schedule) > 0 {
+		schedule, err := ParseSchedule(m.cfg.Schedule, time.Local)
+		if err != nil {
+			return err
+		}
+		go m.runSchedule(ctx, schedule)
+	}
+
+	var (
+		previousBlockHeight int64
+		current             *pb.CandidateBlock
+		next                *pb.CandidateBlock
+		running             bool
+	)
 	for {
 		select {
 		case block := <-blocks:
 			if m.cfg.MineOnce && atomic.LoadUint32(&m.acceptedBlocks) > 0 {
-				return
+				return m.shutdown()
+			}
+			m.publish(TemplateReceived{Time: m.clock.Now(), Block: block})
+
+			if err := m.verify(request, block); err != nil {
+				m.logger.Error().Err(err).Msgf("b[%d] 🚫 refusing to mine template %s", block.Height, block.JobId)
+				continue
+			}
+
+			if sameJob(current, block) {
+				m.logger.Debug().Msgf("b[%d] job %s received again, ignoring it", block.Height, block.JobId)
+				continue
+			}
+
+			if running && !replaces(current, block) {
+				m.logger.Info().Msgf("b[%d] ⏳ job %s queued, finishing the nonce chunk of job %s first", block.Height, block.JobId, current.JobId)
+				next = block
+				m.handover.Store(true)
+				continue
 			}
 
 			m.stop()
+			running = false
+			next = nil
+
+			if previousBlockHeight == 0 {
+				m.resubmitPending(client, block)
+			}
 
 			if previousBlockHeight != 0 && block.Height > previousBlockHeight && m.cfg.BlockSiesta > 0 {
 				m.logger.Info().Msgf("😴 Taking a siesta for %d secs", int(m.cfg.BlockSiesta.Seconds()))
-				time.Sleep(m.cfg.BlockSiesta)
+				select {
+				case <-m.clock.After(m.cfg.BlockSiesta):
+				case <-ctx.Done():
+					return m.shutdown()
+				}
 			}
 
 			previousBlockHeight = block.Height
+			current = block
+
+			if m.Paused() {
+				m.logger.Info().Msgf("b[%d] ⏸️ mining paused, keeping block for later", block.Height)
+				continue
+			}
 
 			m.start(ctx, client, block)
+			running = true
+
+		case <-m.payoutRotated:
+			request = withPayout(request, m.payouts.Address())
+			client.Renew(request)
+
+		case done := <-m.jobDone:
+			if done != current {
+				continue
+			}
+			running = false
+			if next == nil {
+				continue
+			}
+
+			if m.solved.Load() == done {
+				// the queued job is at the height just solved, it would only compete with it
+				m.logger.Info().Msgf("b[%d] 🗑️ dropping job %s, its height was just solved", next.Height, next.JobId)
+				next = nil
+				continue
+			}
+
+			current, next = next, nil
+			if !m.Paused() {
+				m.start(ctx, client, current)
+				running = true
+			}
+
+		case <-m.pauseCh:
+			paused := m.Paused()
+			if paused && running {
+				m.stop()
+				running = false
+				if next != nil {
+					current, next = next, nil
+				}
+			} else if !paused && !running && current != nil && m.solved.Load() != current {
+				m.start(ctx, client, current)
+				running = true
+			}
 
 		case <-ctx.Done():
-			return
+			return m.shutdown()
 		}
 	}
 }
 
+// verify checks block against request as configured, see VerifyTemplate.
+func (m *Miner) verify(request *pb.CandidateRequest, block *pb.CandidateBlock) error {
+	if m.cfg.VerifyTemplates != VerifyStrict && m.cfg.VerifyTemplates != VerifyWarn {
+		return nil
+	}
+	err := VerifyTemplate(block, request, ChainParams(m.cfg.TestNet))
+	if err != nil && m.cfg.VerifyTemplates == VerifyWarn {
+		m.logger.Warn().Err(err).Msgf("b[%d] ⚠️ mining an unverified template", block.Height)
+		return nil
+	}
+	return err
+}
+
+// withPayout asks for templates paying address instead of leaving the xpub to the pool.
+func withPayout(request *pb.CandidateRequest, address string) *pb.CandidateRequest {
+	request = proto.Clone(request).(*pb.CandidateRequest)
+	request.MiningAddrs = []string{address}
+	request.Payouts = nil
+	request.Xpub = ""
+	return request
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// sameJob reports whether block is the job already in hand, sent again by a
+// pool replaying its current job after a reconnection.
+func sameJob(current, block *pb.CandidateBlock) bool {
+	if current == nil {
+		return false
+	}
+	if current.JobId != "" || block.JobId != "" {
+		return current.JobId == block.JobId && current.Header == block.Header
+	}
+	return current.Header == block.Header && bytes.Equal(current.Block, block.Block)
+}
+
+// replaces reports whether block must interrupt the job in progress. Pools
+// tracking jobs flag refreshes that leave earlier jobs valid, those are only
+// picked up once the current job is done.
+func replaces(current, block *pb.CandidateBlock) bool {
+	if current == nil || current.JobId == "" || block.JobId == "" {
+		return true
+	}
+	return block.CleanJobs
+}
+
 func (m *Miner) Generate(ctx context.Context, numBlocks int) {
 
-	client, err := NewClient(m.cfg.PoolServer, m.cfg.PoolTimeout)
+	client, err := m.dial(m.cfg, m.hello())
 	if err != nil {
 		log.Fatal().Err(err).Msgf("Failed to establish connection to the pool server at %s", m.cfg.PoolServer)
 	}
@@ -10,8 +10,10 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"runtime"
+	"slices"
 	"testing"
 	"time"
 
@@ -21,8 +23,11 @@ import (
 
 	"github.com/flokiorg/grpc-miner/mining/algo"
 	"github.com/flokiorg/grpc-miner/mining/pb"
+	"github.com/flokiorg/grpc-miner/utils"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func init() {
@@ -60,6 +65,27 @@ func (cs *clientMockFail) SubmitNonce(ctx context.Context, validBlock *pb.Candid
 	return nil, errors.New("unknown error")
 }
 
+// clientMockDuplicate is a pool that already has every solution.
+type clientMockDuplicate struct {
+}
+
+func (cs *clientMockDuplicate) SubmitNonce(ctx context.Context, validBlock *pb.CandidateBlock, nonce uint32, maxRetries int, maxBackoffSeconds float64) (*pb.AckBlockSubmited, error) {
+	return nil, fmt.Errorf("block submission failed after 1 attempts: %w", status.Error(codes.AlreadyExists, "duplicate submission"))
+}
+
+type clientMockSlow struct {
+	delay time.Duration
+}
+
+func (cs *clientMockSlow) SubmitNonce(ctx context.Context, validBlock *pb.CandidateBlock, nonce uint32, maxRetries int, maxBackoffSeconds float64) (*pb.AckBlockSubmited, error) {
+	select {
+	case <-time.After(cs.delay):
+		return (&clientMockSuccess{}).SubmitNonce(ctx, validBlock, nonce, maxRetries, maxBackoffSeconds)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
 func createCandidateBlock(t *testing.T, diff string) *pb.CandidateBlock {
 	header := "0000e020b4565882aae2f7f6e1fd2b0f2f5501e9f0c7704705fe0100000000000000000002311949e9666728866d73868fccb205dd1fc6e577cfbfaa45ac36936b196c8f9e133567e4c402177fb13bbd"
 
@@ -125,6 +151,7 @@ func TestMiningBlocks(t *testing.T) {
 				miner.wg.Add(1)
 				miner.processCandidate(context.Background(), test.client, block)
 			}
+			miner.submits.Wait()
 
 			if miner.acceptedBlocks != test.expectedBlocks {
 				t.Fatalf("unexpected block mining result, want=%d got=%d", test.expectedBlocks, miner.acceptedBlocks)
@@ -203,9 +230,100 @@ func TestMineWithIssue(t *testing.T) {
 	}
 
 	miner.wg.Wait()
+	miner.submits.Wait()
 
 	if miner.acceptedBlocks != 0 {
 		t.Fatalf("unexpected mining result, block exepcted=%d, got=%d", 0, miner.acceptedBlocks)
 	}
 
 }
+
+func TestShutdownDrainsSubmissions(t *testing.T) {
+
+	hashAlgo, err := algo.Parse("scrypt_cpu")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		delay          time.Duration
+		timeout        time.Duration
+		expectedBlocks uint32
+		expectedErr    error
+	}{
+		{"submission completes", time.Millisecond * 200, time.Second * 5, 1, nil},
+		{"submission abandoned", time.Second * 30, time.Millisecond * 200, 0, ErrSubmissionAbandoned},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := &common.Config{
+				Threads:         uint8(runtime.NumCPU()),
+				ShutdownTimeout: test.timeout,
+			}
+
+			miner := NewMiner(cfg, hashAlgo, &pb.CandidateRequest{}, log.Logger)
+			miner.wg.Add(1)
+			miner.processCandidate(context.Background(), &clientMockSlow{delay: test.delay}, createCandidateBlock(t, "207fffff"))
+
+			if err := miner.shutdown(); !errors.Is(err, test.expectedErr) {
+				t.Fatalf("unexpected shutdown result, want=%v got=%v", test.expectedErr, err)
+			}
+			if miner.acceptedBlocks != test.expectedBlocks {
+				t.Fatalf("unexpected accepted blocks, want=%d got=%d", test.expectedBlocks, miner.acceptedBlocks)
+			}
+		})
+	}
+}
+
+func TestReplaces(t *testing.T) {
+	legacy := createCandidateBlock(t, "207fffff")
+
+	job := createCandidateBlock(t, "207fffff")
+	job.JobId = "1"
+
+	refresh := createCandidateBlock(t, "207fffff")
+	refresh.JobId = "2"
+
+	clean := createCandidateBlock(t, "207fffff")
+	clean.JobId = "3"
+	clean.CleanJobs = true
+
+	tests := []struct {
+		name     string
+		current  *pb.CandidateBlock
+		block    *pb.CandidateBlock
+		replaces bool
+	}{
+		{"first job", nil, job, true},
+		{"legacy pool", legacy, legacy, true},
+		{"refresh", job, refresh, false},
+		{"clean job", job, clean, true},
+		{"job after legacy template", legacy, refresh, true},
+	}
+
+	for _, test := range tests {
+		if got := replaces(test.current, test.block); got != test.replaces {
+			t.Fatalf("%s: want=%v got=%v", test.name, test.replaces, got)
+		}
+	}
+}
+
+func TestNonceChunks(t *testing.T) {
+	tests := []struct {
+		r      utils.MinMax
+		size   uint32
+		chunks []utils.MinMax
+	}{
+		{utils.MinMax{Min: 0, Max: 9}, 4, []utils.MinMax{{Min: 0, Max: 3}, {Min: 4, Max: 7}, {Min: 8, Max: 9}}},
+		{utils.MinMax{Min: 2, Max: 5}, 4, []utils.MinMax{{Min: 2, Max: 5}}},
+		{utils.MinMax{Min: math.MaxUint32 - 5, Max: math.MaxUint32}, 4, []utils.MinMax{{Min: math.MaxUint32 - 5, Max: math.MaxUint32 - 2}, {Min: math.MaxUint32 - 1, Max: math.MaxUint32}}},
+	}
+
+	for _, test := range tests {
+		if got := slices.Collect(nonceChunks(test.r, test.size)); !slices.Equal(got, test.chunks) {
+			t.Fatalf("chunks of %+v by %d: want=%v got=%v", test.r, test.size, test.chunks, got)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package mining
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/flokiorg/go-flokicoin/chaincfg"
+	"github.com/flokiorg/go-flokicoin/chainutil"
+	"github.com/flokiorg/go-flokicoin/chainutil/hdkeychain"
+	"github.com/flokiorg/grpc-miner/mining/pb"
+)
+
+var errOtherNetwork = errors.New("address is for another network")
+
+// accountDepth is the depth of a BIP32 account key, m/purpose'/coin'/account'.
+const accountDepth = 3
+
+// ChainParams returns the network the miner pays to.
+func ChainParams(testnet bool) *chaincfg.Params {
+	if testnet {
+		return &chaincfg.TestNet3Params
+	}
+	return &chaincfg.MainNetParams
+}
+
+// ParsePayouts validates mining addresses given as addr or addr:weight
+// against params. Weights are only returned when at least one is given, the
+// pool then splits the reward instead of paying the addresses in turn.
+func ParsePayouts(values []string, params *chaincfg.Params) ([]string, []*pb.Payout, error) {
+	var (
+		addrs    []string
+		payouts  []*pb.Payout
+		weighted bool
+		seen     = make(map[string]bool)
+	)
+	for _, value := range values {
+		encoded, weight := strings.TrimSpace(value), uint64(1)
+		if i := strings.LastIndex(encoded, ":"); i >= 0 {
+			var err error
+			if weight, err = strconv.ParseUint(encoded[i+1:], 10, 32); err != nil || weight == 0 {
+				return nil, nil, fmt.Errorf("invalid weight in %q, expected a positive integer", value)
+			}
+			encoded, weighted = encoded[:i], true
+		}
+
+		addr, err := chainutil.DecodeAddress(encoded, params)
+		if err == nil && !addr.IsForNet(params) {
+			err = errOtherNetwork
+		}
+		if err != nil {
+			// tell a wrong network apart from a typo
+			other := ChainParams(params.Net == chaincfg.MainNetParams.Net)
+			if _, otherErr := chainutil.DecodeAddress(encoded, other); errors.Is(err, errOtherNetwork) || otherErr == nil {
+				return nil, nil, fmt.Errorf("mining address %q is not for %s, check --testnet", encoded, params.Name)
+			}
+			return nil, nil, fmt.Errorf("invalid mining address %q: %w", encoded, err)
+		}
+		if seen[addr.EncodeAddress()] {
+			return nil, nil, fmt.Errorf("mining address %q is listed twice", encoded)
+		}
+		seen[addr.EncodeAddress()] = true
+
+		addrs = append(addrs, addr.EncodeAddress())
+		payouts = append(payouts, &pb.Payout{Address: addr.EncodeAddress(), Weight: uint32(weight)})
+	}
+
+	if !weighted {
+		payouts = nil
+	}
+	return addrs, payouts, nil
+}
+
+// ParseXpub checks that xpub is an account-level extended public key of params.
+func ParseXpub(xpub string, params *chaincfg.Params) (*hdkeychain.ExtendedKey, error) {
+	key, err := hdkeychain.NewKeyFromString(strings.TrimSpace(xpub))
+	if err != nil {
+		return nil, fmt.Errorf("malformed xpub: %w", err)
+	}
+	if key.IsPrivate() {
+		return nil, fmt.Errorf("refusing an extended private key, export the account xpub instead")
+	}
+	if !key.IsForNet(params) {
+		return nil, fmt.Errorf("xpub is not for %s", params.Name)
+	}
+	if key.Depth() != accountDepth {
+		return nil, fmt.Errorf("expected an account-level xpub (m/44'/coin'/account'), got depth %d", key.Depth())
+	}
+	return key, nil
+}
+
+// XpubPayouts hands out the receive addresses of an account xpub, m/0/i, one
+// per found block. The index is persisted to path so an address is never
+// reused across restarts, it is only kept in memory when path is empty.
+type XpubPayouts struct {
+	mu       sync.Mutex
+	external *hdkeychain.ExtendedKey
+	params   *chaincfg.Params
+	path     string
+	index    uint32
+	address  string
+}
+
+func OpenXpubPayouts(xpub string, params *chaincfg.Params, path string) (*XpubPayouts, error) {
+	key, err := ParseXpub(xpub, params)
+	if err != nil {
+		return nil, err
+	}
+	external, err := key.Derive(0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive the receive chain: %w", err)
+	}
+
+	p := &XpubPayouts{external: external, params: params, path: path}
+	if p.index, err = readIndex(path); err != nil {
+		return nil, err
+	}
+	if err := p.derive(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Address returns the address blocks are currently paid to.
+func (p *XpubPayouts) Address() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.address
+}
+
+// Index returns the derivation index of Address.
+func (p *XpubPayouts) Index() uint32 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.index
+}
+
+// Rotate moves to the next address once the current one received a block.
+// The new index is persisted before the address is handed out.
+func (p *XpubPayouts) Rotate() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	previous := p.index
+	p.index++
+	if err := p.derive(); err != nil {
+		p.index = previous
+		return "", err
+	}
+	if err := writeIndex(p.path, p.index); err != nil {
+		p.index = previous
+		p.derive()
+		return "", err
+	}
+	return p.address, nil
+}
+
+// derive sets the address at the current index, skipping the rare indexes
+// that do not yield a valid key as BIP32 prescribes.
+func (p *XpubPayouts) derive() error {
+	for {
+		child, err := p.external.Derive(p.index)
+		if err == hdkeychain.ErrInvalidChild {
+			p.index++
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to derive address %d: %w", p.index, err)
+		}
+		addr, err := child.Address(p.params)
+		if err != nil {
+			return fmt.Errorf("failed to derive address %d: %w", p.index, err)
+		}
+		p.address = addr.EncodeAddress()
+		return nil
+	}
+}
+
+func readIndex(path string) (uint32, error) {
+	if path == "" {
+		return 0, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read xpub index: %w", err)
+	}
+	index, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("corrupted xpub index %s: %w", path, err)
+	}
+	return uint32(index), nil
+}
+
+// writeIndex replaces the index file atomically, a torn write must not send
+// the miner back to used addresses.
+func writeIndex(path string, index uint32) error {
+	if path == "" {
+		return nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to save xpub index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(strconv.FormatUint(uint64(index), 10) + "\n"); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save xpub index: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save xpub index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save xpub index: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save xpub index: %w", err)
+	}
+	return nil
+}
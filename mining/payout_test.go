@@ -0,0 +1,178 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package mining
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/flokiorg/go-flokicoin/chaincfg"
+	"github.com/flokiorg/go-flokicoin/chainutil"
+	"github.com/flokiorg/go-flokicoin/chainutil/hdkeychain"
+)
+
+// accountKey derives m/44'/1'/0' of a fixed seed.
+func accountKey(t *testing.T, params *chaincfg.Params) *hdkeychain.ExtendedKey {
+	t.Helper()
+
+	key, err := hdkeychain.NewMaster(make([]byte, hdkeychain.RecommendedSeedLen), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, i := range []uint32{44, 1, 0} {
+		if key, err = key.Derive(hdkeychain.HardenedKeyStart + i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return key
+}
+
+func receiveAddress(t *testing.T, account *hdkeychain.ExtendedKey, params *chaincfg.Params, index uint32) string {
+	t.Helper()
+
+	external, err := account.Derive(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := external.Derive(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := child.Address(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addr.EncodeAddress()
+}
+
+func TestParseXpub(t *testing.T) {
+	params := &chaincfg.TestNet3Params
+	account := accountKey(t, params)
+	xpub, _ := account.Neuter()
+	parent, _ := accountKey(t, params).Derive(0)
+	parentXpub, _ := parent.Neuter()
+	mainnet := accountKey(t, &chaincfg.MainNetParams)
+	mainnetXpub, _ := mainnet.Neuter()
+
+	tests := []struct {
+		name string
+		key  string
+		fail string
+	}{
+		{"account xpub", xpub.String(), ""},
+		{"surrounding spaces", " " + xpub.String() + "\n", ""},
+		{"private key", account.String(), "private"},
+		{"other network", mainnetXpub.String(), "not for"},
+		{"not an account", parentXpub.String(), "depth 4"},
+		{"garbage", "xpub123", "malformed"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseXpub(test.key, params)
+			if test.fail == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.fail != "" && (err == nil || !strings.Contains(err.Error(), test.fail)) {
+				t.Fatalf("expected an error about %q, got=%v", test.fail, err)
+			}
+		})
+	}
+}
+
+func TestXpubPayouts(t *testing.T) {
+	params := &chaincfg.TestNet3Params
+	account := accountKey(t, params)
+	xpub, _ := account.Neuter()
+	path := filepath.Join(t.TempDir(), "gminer.xpub")
+
+	payouts, err := OpenXpubPayouts(xpub.String(), params, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := receiveAddress(t, account, params, 0); payouts.Address() != want {
+		t.Fatalf("unexpected first address, want=%s got=%s", want, payouts.Address())
+	}
+
+	for i := uint32(1); i <= 2; i++ {
+		address, err := payouts.Rotate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := receiveAddress(t, account, params, i); address != want || payouts.Index() != i {
+			t.Fatalf("rotation %d: want=%s got=%s (index %d)", i, want, address, payouts.Index())
+		}
+	}
+
+	reopened, err := OpenXpubPayouts(xpub.String(), params, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Index() != 2 || reopened.Address() != payouts.Address() {
+		t.Fatalf("derivation index not restored, got index %d", reopened.Index())
+	}
+}
+
+func TestParsePayouts(t *testing.T) {
+	params := &chaincfg.TestNet3Params
+	address := func(b byte, params *chaincfg.Params) string {
+		addr, err := chainutil.NewAddressPubKeyHash(bytesOf(b), params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return addr.EncodeAddress()
+	}
+	first, second := address(1, params), address(2, params)
+
+	tests := []struct {
+		name    string
+		values  []string
+		weights []uint32
+		fail    string
+	}{
+		{"plain addresses", []string{first, second}, nil, ""},
+		{"weighted split", []string{first + ":3", second}, []uint32{3, 1}, ""},
+		{"zero weight", []string{first + ":0"}, nil, "invalid weight"},
+		{"bad weight", []string{first + ":half"}, nil, "invalid weight"},
+		{"typo", []string{first[:len(first)-1] + "x"}, nil, "invalid mining address"},
+		{"other network", []string{address(1, &chaincfg.MainNetParams)}, nil, "check --testnet"},
+		{"duplicate", []string{first, first + ":2"}, nil, "listed twice"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			addrs, payouts, err := ParsePayouts(test.values, params)
+			if test.fail != "" {
+				if err == nil || !strings.Contains(err.Error(), test.fail) {
+					t.Fatalf("expected an error about %q, got=%v", test.fail, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(addrs) != len(test.values) || addrs[0] != first {
+				t.Fatalf("unexpected addresses %v", addrs)
+			}
+			if len(payouts) != len(test.weights) {
+				t.Fatalf("unexpected payouts %v", payouts)
+			}
+			for i, weight := range test.weights {
+				if payouts[i].Address != addrs[i] || payouts[i].Weight != weight {
+					t.Fatalf("payout %d: want %s:%d got %s:%d", i, addrs[i], weight, payouts[i].Address, payouts[i].Weight)
+				}
+			}
+		})
+	}
+}
+
+func bytesOf(b byte) []byte {
+	hash := make([]byte, 20)
+	for i := range hash {
+		hash[i] = b
+	}
+	return hash
+}
@@ -21,7 +21,11 @@ const _ = grpc.SupportPackageIsVersion9
 const (
 	CandidateStream_Open_FullMethodName             = "/proto.CandidateStream/Open"
 	CandidateStream_SubmitValidBlock_FullMethodName = "/proto.CandidateStream/SubmitValidBlock"
+	CandidateStream_SubmitCompact_FullMethodName    = "/proto.CandidateStream/SubmitCompact"
 	CandidateStream_Generate_FullMethodName         = "/proto.CandidateStream/Generate"
+	CandidateStream_Hello_FullMethodName            = "/proto.CandidateStream/Hello"
+	CandidateStream_Session_FullMethodName          = "/proto.CandidateStream/Session"
+	CandidateStream_BlockStatus_FullMethodName      = "/proto.CandidateStream/BlockStatus"
 )
 
 // CandidateStreamClient is the client API for CandidateStream service.
@@ -30,7 +34,11 @@ const (
 type CandidateStreamClient interface {
 	Open(ctx context.Context, in *CandidateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CandidateBlock], error)
 	SubmitValidBlock(ctx context.Context, in *ValidBlock, opts ...grpc.CallOption) (*AckBlockSubmited, error)
+	SubmitCompact(ctx context.Context, in *CompactSolution, opts ...grpc.CallOption) (*AckBlockSubmited, error)
 	Generate(ctx context.Context, in *GenerateRequest, opts ...grpc.CallOption) (*GenerateResponse, error)
+	Hello(ctx context.Context, in *HelloRequest, opts ...grpc.CallOption) (*HelloResponse, error)
+	Session(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MinerMessage, PoolMessage], error)
+	BlockStatus(ctx context.Context, in *BlockStatusRequest, opts ...grpc.CallOption) (*BlockStatusResponse, error)
 }
 
 type candidateStreamClient struct {
@@ -70,6 +78,16 @@ func (c *candidateStreamClient) SubmitValidBlock(ctx context.Context, in *ValidB
 	return out, nil
 }
 
+func (c *candidateStreamClient) SubmitCompact(ctx context.Context, in *CompactSolution, opts ...grpc.CallOption) (*AckBlockSubmited, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AckBlockSubmited)
+	err := c.cc.Invoke(ctx, CandidateStream_SubmitCompact_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *candidateStreamClient) Generate(ctx context.Context, in *GenerateRequest, opts ...grpc.CallOption) (*GenerateResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(GenerateResponse)
@@ -80,13 +98,50 @@ func (c *candidateStreamClient) Generate(ctx context.Context, in *GenerateReques
 	return out, nil
 }
 
+func (c *candidateStreamClient) Hello(ctx context.Context, in *HelloRequest, opts ...grpc.CallOption) (*HelloResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HelloResponse)
+	err := c.cc.Invoke(ctx, CandidateStream_Hello_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *candidateStreamClient) Session(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MinerMessage, PoolMessage], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CandidateStream_ServiceDesc.Streams[1], CandidateStream_Session_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[MinerMessage, PoolMessage]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CandidateStream_SessionClient = grpc.BidiStreamingClient[MinerMessage, PoolMessage]
+
+func (c *candidateStreamClient) BlockStatus(ctx context.Context, in *BlockStatusRequest, opts ...grpc.CallOption) (*BlockStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BlockStatusResponse)
+	err := c.cc.Invoke(ctx, CandidateStream_BlockStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CandidateStreamServer is the server API for CandidateStream service.
 // All implementations must embed UnimplementedCandidateStreamServer
 // for forward compatibility.
 type CandidateStreamServer interface {
 	Open(*CandidateRequest, grpc.ServerStreamingServer[CandidateBlock]) error
 	SubmitValidBlock(context.Context, *ValidBlock) (*AckBlockSubmited, error)
+	SubmitCompact(context.Context, *CompactSolution) (*AckBlockSubmited, error)
 	Generate(context.Context, *GenerateRequest) (*GenerateResponse, error)
+	Hello(context.Context, *HelloRequest) (*HelloResponse, error)
+	Session(grpc.BidiStreamingServer[MinerMessage, PoolMessage]) error
+	BlockStatus(context.Context, *BlockStatusRequest) (*BlockStatusResponse, error)
 	mustEmbedUnimplementedCandidateStreamServer()
 }
 
@@ -103,9 +158,21 @@ func (UnimplementedCandidateStreamServer) Open(*CandidateRequest, grpc.ServerStr
 func (UnimplementedCandidateStreamServer) SubmitValidBlock(context.Context, *ValidBlock) (*AckBlockSubmited, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SubmitValidBlock not implemented")
 }
+func (UnimplementedCandidateStreamServer) SubmitCompact(context.Context, *CompactSolution) (*AckBlockSubmited, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitCompact not implemented")
+}
 func (UnimplementedCandidateStreamServer) Generate(context.Context, *GenerateRequest) (*GenerateResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Generate not implemented")
 }
+func (UnimplementedCandidateStreamServer) Hello(context.Context, *HelloRequest) (*HelloResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Hello not implemented")
+}
+func (UnimplementedCandidateStreamServer) Session(grpc.BidiStreamingServer[MinerMessage, PoolMessage]) error {
+	return status.Errorf(codes.Unimplemented, "method Session not implemented")
+}
+func (UnimplementedCandidateStreamServer) BlockStatus(context.Context, *BlockStatusRequest) (*BlockStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BlockStatus not implemented")
+}
 func (UnimplementedCandidateStreamServer) mustEmbedUnimplementedCandidateStreamServer() {}
 func (UnimplementedCandidateStreamServer) testEmbeddedByValue()                         {}
 
@@ -156,6 +223,24 @@ func _CandidateStream_SubmitValidBlock_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CandidateStream_SubmitCompact_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompactSolution)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CandidateStreamServer).SubmitCompact(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CandidateStream_SubmitCompact_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CandidateStreamServer).SubmitCompact(ctx, req.(*CompactSolution))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CandidateStream_Generate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GenerateRequest)
 	if err := dec(in); err != nil {
@@ -174,6 +259,49 @@ func _CandidateStream_Generate_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CandidateStream_Hello_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HelloRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CandidateStreamServer).Hello(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CandidateStream_Hello_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CandidateStreamServer).Hello(ctx, req.(*HelloRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CandidateStream_Session_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(CandidateStreamServer).Session(&grpc.GenericServerStream[MinerMessage, PoolMessage]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CandidateStream_SessionServer = grpc.BidiStreamingServer[MinerMessage, PoolMessage]
+
+func _CandidateStream_BlockStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CandidateStreamServer).BlockStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CandidateStream_BlockStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CandidateStreamServer).BlockStatus(ctx, req.(*BlockStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // CandidateStream_ServiceDesc is the grpc.ServiceDesc for CandidateStream service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -185,10 +313,22 @@ var CandidateStream_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SubmitValidBlock",
 			Handler:    _CandidateStream_SubmitValidBlock_Handler,
 		},
+		{
+			MethodName: "SubmitCompact",
+			Handler:    _CandidateStream_SubmitCompact_Handler,
+		},
 		{
 			MethodName: "Generate",
 			Handler:    _CandidateStream_Generate_Handler,
 		},
+		{
+			MethodName: "Hello",
+			Handler:    _CandidateStream_Hello_Handler,
+		},
+		{
+			MethodName: "BlockStatus",
+			Handler:    _CandidateStream_BlockStatus_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -196,6 +336,12 @@ var CandidateStream_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _CandidateStream_Open_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "Session",
+			Handler:       _CandidateStream_Session_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "packet.proto",
 }
@@ -0,0 +1,49 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package mining
+
+import (
+	"context"
+
+	"github.com/flokiorg/grpc-miner/common"
+	. "github.com/flokiorg/grpc-miner/mining/algo/common"
+	"github.com/flokiorg/grpc-miner/mining/pb"
+)
+
+// PoolClient is how a Miner talks to its pool. Client speaks the gRPC
+// protocol, other transports only have to implement this interface and be
+// handed to the Miner through a ClientFactory.
+type PoolClient interface {
+	ClientService
+
+	// Listen sends the templates built for request to blocks until ctx is
+	// done, reconnecting whenever the job stream breaks.
+	Listen(ctx context.Context, request *pb.CandidateRequest, blocks chan<- *pb.CandidateBlock)
+
+	// Renew replaces the request Listen asks templates for.
+	Renew(request *pb.CandidateRequest)
+
+	Generate(ctx context.Context, blocks int) ([]string, error)
+
+	// BlockStatus returns ErrBlockStatusUnsupported when the pool cannot
+	// tell where blocks stand.
+	BlockStatus(ctx context.Context, hashes []string) ([]*pb.BlockInfo, error)
+
+	Health(ctx context.Context) (pb.HealthStatus, error)
+
+	// Supports reports whether a feature was negotiated with the pool.
+	Supports(feature pb.Feature) bool
+	Close()
+}
+
+// ClientFactory connects to the pool of cfg, hello describing the miner.
+type ClientFactory func(cfg *common.Config, hello *pb.HelloRequest) (PoolClient, error)
+
+// DialClient is the default ClientFactory, connecting over gRPC.
+func DialClient(cfg *common.Config, hello *pb.HelloRequest) (PoolClient, error) {
+	return NewClient(cfg, hello)
+}
+
+var _ PoolClient = (*Client)(nil)
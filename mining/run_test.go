@@ -0,0 +1,452 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package mining_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flokiorg/grpc-miner/common"
+	"github.com/flokiorg/grpc-miner/mining"
+	. "github.com/flokiorg/grpc-miner/mining/algo/common"
+	"github.com/flokiorg/grpc-miner/mining/pb"
+	"github.com/flokiorg/grpc-miner/mining/sim"
+	"github.com/flokiorg/grpc-miner/utils"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const simTimeout = 5 * time.Second
+
+// simulation is a miner running against a simulated pool and algo.
+type simulation struct {
+	pool   *sim.Pool
+	algo   *sim.Algo
+	miner  *mining.Miner
+	cancel context.CancelFunc
+	done   chan error
+}
+
+func simulate(t *testing.T, configure func(*simulation, *common.Config)) *simulation {
+	t.Helper()
+
+	s := &simulation{pool: sim.NewPool(), algo: sim.NewAlgo(), done: make(chan error, 1)}
+	addr, stop, err := s.pool.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(stop)
+
+	cfg := &common.Config{
+		PoolServer:        addr,
+		PoolTimeout:       simTimeout,
+		Threads:           1,
+		MinBackoffSeconds: 0.01,
+		MaxBackoffSeconds: 0.05,
+		ShutdownTimeout:   simTimeout,
+	}
+	s.miner = mining.NewMiner(cfg, s.algo, &pb.CandidateRequest{MiningAddrs: []string{"sim"}}, log.Logger)
+	if configure != nil {
+		configure(s, cfg)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	go func() { s.done <- s.miner.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		<-s.done
+	})
+
+	if _, err := s.pool.WaitStreams(1, simTimeout); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// mined returns the heights of the jobs the algo was given, in order.
+func (s *simulation) mined(t *testing.T, n int) []int64 {
+	t.Helper()
+	attempts, err := s.algo.WaitAttempts(n, simTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	heights := make([]int64, len(attempts))
+	for i, attempt := range attempts {
+		heights[i] = attempt.Block.Height
+	}
+	return heights
+}
+
+func (s *simulation) waitAccepted(t *testing.T, n uint32) {
+	t.Helper()
+	deadline := time.Now().Add(simTimeout)
+	for s.miner.Accepted() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("accepted %d blocks, want %d", s.miner.Accepted(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (s *simulation) stopped(t *testing.T) error {
+	t.Helper()
+	select {
+	case err := <-s.done:
+		s.done <- err
+		return err
+	case <-time.After(simTimeout):
+		t.Fatal("miner did not stop")
+		return nil
+	}
+}
+
+func TestRunMineOnce(t *testing.T) {
+	s := simulate(t, func(_ *simulation, cfg *common.Config) { cfg.MineOnce = true })
+
+	s.algo.Solve("1", 42)
+	s.pool.Push(sim.Template(1))
+
+	submissions, err := s.pool.WaitSubmissions(1, simTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if submissions[0].Nonce != 42 || submissions[0].Block.Height != 1 {
+		t.Fatalf("unexpected submission %+v", submissions[0])
+	}
+	s.waitAccepted(t, 1)
+
+	// the next template ends the run instead of being mined
+	s.pool.Push(sim.Template(2))
+	if err := s.stopped(t); err != nil {
+		t.Fatal(err)
+	}
+	if heights := s.mined(t, 1); len(heights) != 1 {
+		t.Fatalf("mined %v after the first block", heights)
+	}
+}
+
+func TestRunStop(t *testing.T) {
+	s := simulate(t, nil)
+
+	s.pool.Push(sim.Template(1))
+	s.mined(t, 1)
+
+	s.cancel()
+	if err := s.stopped(t); err != nil {
+		t.Fatal(err)
+	}
+	if submissions := s.pool.Submissions(); len(submissions) != 0 {
+		t.Fatalf("unexpected submissions %+v", submissions)
+	}
+}
+
+func TestRunSiesta(t *testing.T) {
+	clock := utils.NewFakeClock(time.Unix(0, 0))
+	s := simulate(t, func(s *simulation, cfg *common.Config) {
+		cfg.BlockSiesta = 10 * time.Minute
+		s.miner.SetClock(clock)
+	})
+
+	// no siesta before the first block
+	s.pool.Push(sim.Template(1))
+	s.mined(t, 1)
+
+	s.pool.Push(sim.Template(2))
+	clock.BlockUntil(1)
+	if attempts := s.algo.Attempts(); len(attempts) != 1 {
+		t.Fatalf("mined %d jobs during the siesta", len(attempts))
+	}
+
+	clock.Advance(10 * time.Minute)
+	if heights := s.mined(t, 2); heights[1] != 2 {
+		t.Fatalf("unexpected jobs %v", heights)
+	}
+}
+
+func TestRunFaults(t *testing.T) {
+	tests := []struct {
+		name  string
+		fault func(*testing.T, *sim.Pool)
+	}{
+		{"dropped stream", func(t *testing.T, p *sim.Pool) {
+			p.Drop()
+			// the pool replays its current job on the new stream
+			if _, err := p.WaitStreams(2, simTimeout); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"template source down", func(t *testing.T, p *sim.Pool) {
+			p.FailTemplates(status.Error(codes.Unavailable, "node syncing"))
+			p.Drop()
+			if _, err := p.WaitStreams(2, simTimeout); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"duplicate template", func(t *testing.T, p *sim.Pool) {
+			p.Push(sim.Template(1))
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := simulate(t, nil)
+
+			s.pool.Push(sim.Template(1))
+			s.mined(t, 1)
+
+			test.fault(t, s.pool)
+
+			s.algo.Solve("2", 7)
+			s.pool.Push(sim.Template(2))
+			if _, err := s.pool.WaitSubmissions(1, simTimeout); err != nil {
+				t.Fatal(err)
+			}
+
+			// the job in hand was not restarted
+			if heights := s.mined(t, 2); len(heights) != 2 || heights[0] != 1 || heights[1] != 2 {
+				t.Fatalf("unexpected jobs %v", heights)
+			}
+		})
+	}
+}
+
+func TestRunHandover(t *testing.T) {
+	received := make(chan string, 4)
+	s := simulate(t, func(s *simulation, _ *common.Config) {
+		s.miner.Events().Subscribe(func(e mining.Event) {
+			if e, ok := e.(mining.TemplateReceived); ok {
+				received <- e.Block.JobId
+			}
+		})
+	})
+
+	s.pool.Push(sim.Template(1))
+	s.mined(t, 1)
+
+	// a refresh leaving the current job valid waits for the end of its nonce chunk
+	refresh := sim.Template(1)
+	refresh.JobId, refresh.CleanJobs = "1.1", false
+	s.pool.Push(refresh)
+	for id := ""; id != refresh.JobId; {
+		select {
+		case id = <-received:
+		case <-time.After(simTimeout):
+			t.Fatal("refresh not received")
+		}
+	}
+
+	for n := 2; ; n++ {
+		s.algo.EndChunk()
+		attempts, err := s.algo.WaitAttempts(n, simTimeout)
+		if err != nil {
+			t.Fatal(err)
+		}
+		last := attempts[n-1]
+		if last.Block.JobId == refresh.JobId {
+			if last.Range.Min != 0 {
+				t.Fatalf("refresh started at nonce %d", last.Range.Min)
+			}
+			break
+		}
+		// the refresh was not queued yet, the job went on with its next chunk
+		if want := uint32(n-1) * NONCE_CHUNK; last.Block.JobId != "1" || last.Range.Min != want {
+			t.Fatalf("unexpected attempt %+v, want job 1 from nonce %d", last, want)
+		}
+		if n > 100 {
+			t.Fatal("refresh never took over")
+		}
+	}
+}
+
+func TestRunInProcess(t *testing.T) {
+	var dialed *pb.HelloRequest
+	health := make(chan mining.HealthChanged, 4)
+	s := simulate(t, func(s *simulation, _ *common.Config) {
+		s.miner.SetClientFactory(func(_ *common.Config, hello *pb.HelloRequest) (mining.PoolClient, error) {
+			dialed = hello
+			return sim.NewClient(s.pool), nil
+		})
+		s.miner.Events().Subscribe(func(e mining.Event) {
+			if e, ok := e.(mining.HealthChanged); ok {
+				health <- e
+			}
+		})
+		s.pool.FailTemplates(status.Error(codes.Unavailable, "node syncing"))
+	})
+	if dialed == nil || dialed.ProtocolVersion != mining.ProtocolVersion {
+		t.Fatalf("unexpected hello %v", dialed)
+	}
+
+	// the stream the pool failed to open is reported before the next one
+	select {
+	case e := <-health:
+		if e.Status != pb.HealthStatus_NOT_SERVING || !strings.Contains(e.Message, "node syncing") {
+			t.Fatalf("unexpected health %+v", e)
+		}
+	case <-time.After(simTimeout):
+		t.Fatal("failed stream not reported")
+	}
+
+	s.algo.Solve("1", 9)
+	s.pool.Push(sim.Template(1))
+	if _, err := s.pool.WaitSubmissions(1, simTimeout); err != nil {
+		t.Fatal(err)
+	}
+	s.waitAccepted(t, 1)
+
+	// the pool was only reached through the factory
+	if n := s.pool.Server.Peers(); n != 0 {
+		t.Fatalf("%d peers connected over gRPC", n)
+	}
+}
+
+func TestRunFailedSubmits(t *testing.T) {
+	t.Run("retried", func(t *testing.T) {
+		s := simulate(t, func(_ *simulation, cfg *common.Config) { cfg.MaxRetries = 2 })
+		s.pool.FailSubmits(status.Error(codes.Unavailable, "node down"), status.Error(codes.Unavailable, "node down"))
+
+		s.algo.Solve("1", 3)
+		s.pool.Push(sim.Template(1))
+
+		submissions, err := s.pool.WaitSubmissions(3, simTimeout)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if submissions[0].Err == nil || submissions[1].Err == nil || submissions[2].Err != nil {
+			t.Fatalf("unexpected submissions %+v", submissions)
+		}
+		s.waitAccepted(t, 1)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		s := simulate(t, func(_ *simulation, cfg *common.Config) { cfg.MaxRetries = 2 })
+		s.pool.FailSubmits(status.Error(codes.FailedPrecondition, "bad block"))
+
+		s.algo.Solve("1", 3)
+		s.pool.Push(sim.Template(1))
+		if _, err := s.pool.WaitSubmissions(1, simTimeout); err != nil {
+			t.Fatal(err)
+		}
+
+		// the rejected block is not retried, the next one goes through
+		s.algo.Solve("2", 5)
+		s.pool.Push(sim.Template(2))
+		submissions, err := s.pool.WaitSubmissions(2, simTimeout)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if submissions[1].Block.Height != 2 || submissions[1].Err != nil {
+			t.Fatalf("unexpected submissions %+v", submissions)
+		}
+		s.waitAccepted(t, 1)
+	})
+}
+
+func TestRunEvents(t *testing.T) {
+	events := make(chan mining.Event, 64)
+	s := simulate(t, func(s *simulation, cfg *common.Config) {
+		s.miner.Events().Subscribe(func(e mining.Event) { events <- e })
+	})
+	s.pool.FailSubmits(status.Error(codes.FailedPrecondition, "bad block"))
+
+	// kinds returns the events of a job up to the outcome of its submission
+	kinds := func() []string {
+		t.Helper()
+		var got []string
+		for {
+			var e mining.Event
+			select {
+			case e = <-events:
+			case <-time.After(simTimeout):
+				t.Fatalf("no submission outcome, events so far %v", got)
+			}
+			got = append(got, fmt.Sprintf("%T", e))
+
+			switch e := e.(type) {
+			case mining.SolutionFound:
+				// the simulated algo reports the nonce as the hash
+				if want := fmt.Sprintf("%064x", e.Nonce); e.Hash != want {
+					t.Fatalf("unexpected solved hash, want=%s got=%s", want, e.Hash)
+				}
+			case mining.SubmitRejected:
+				if e.Stale() || e.Retry || status.Code(e.Err) != codes.FailedPrecondition {
+					t.Fatalf("unexpected rejection %+v", e)
+				}
+				return got
+			case mining.SubmitAccepted:
+				return got
+			}
+		}
+	}
+
+	s.algo.Solve("1", 3)
+	s.pool.Push(sim.Template(1))
+	want := []string{"mining.TemplateReceived", "mining.MiningStarted", "mining.SolutionFound", "mining.SubmitRejected"}
+	if got := kinds(); !slices.Equal(got, want) {
+		t.Fatalf("unexpected events\nwant=%v\n got=%v", want, got)
+	}
+
+	s.algo.Solve("2", 5)
+	s.pool.Push(sim.Template(2))
+	want = []string{"mining.TemplateReceived", "mining.MiningStarted", "mining.SolutionFound", "mining.SubmitAccepted"}
+	if got := kinds(); !slices.Equal(got, want) {
+		t.Fatalf("unexpected events\nwant=%v\n got=%v", want, got)
+	}
+	s.waitAccepted(t, 1)
+}
+
+func TestRunCoinbaseTextUnsupported(t *testing.T) {
+	for _, test := range []struct {
+		text  string
+		fails bool
+	}{
+		{text: "/plain text/"},
+		{text: "/{worker}/", fails: true},
+		{text: `\xff`, fails: true},
+	} {
+		t.Run(test.text, func(t *testing.T) {
+			// a pool predating coinbase text variables
+			p := sim.NewPool()
+			p.Server.Features = slices.DeleteFunc(p.Server.Features, func(f pb.Feature) bool {
+				return f == pb.Feature_FEATURE_COINBASE_TEXT
+			})
+			addr, stop, err := p.Start()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer stop()
+
+			cfg := &common.Config{PoolServer: addr, PoolTimeout: simTimeout, Threads: 1, ShutdownTimeout: simTimeout}
+			request := &pb.CandidateRequest{MiningAddrs: []string{"sim"}, CoinbaseScript: &pb.CoinbaseScript{Text: test.text}}
+			miner := mining.NewMiner(cfg, sim.NewAlgo(), request, log.Logger)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			done := make(chan error, 1)
+			go func() { done <- miner.Run(ctx) }()
+
+			if !test.fails {
+				if _, err := p.WaitStreams(1, simTimeout); err != nil {
+					t.Fatal(err)
+				}
+				cancel()
+			}
+			select {
+			case err := <-done:
+				if (err != nil) != test.fails {
+					t.Fatalf("unexpected error %v", err)
+				}
+			case <-time.After(simTimeout):
+				t.Fatal("miner did not stop")
+			}
+		})
+	}
+}
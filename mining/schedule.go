@@ -0,0 +1,247 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package mining
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const pauseReasonSchedule = "schedule"
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// window is a daily time range, starting on the selected days and possibly
+// running past midnight into the next day.
+type window struct {
+	days       [7]bool
+	startHour  int
+	startMin   int
+	endHour    int
+	endMin     int
+	overnight  bool
+	definition string
+}
+
+// Schedule is a set of mining windows such as "Mon-Fri 22:00-06:00".
+type Schedule struct {
+	windows []window
+	loc     *time.Location
+}
+
+// ParseSchedule parses windows in the format "[days] HH:MM-HH:MM", where days
+// is a comma separated list of weekdays or ranges (Mon-Fri,Sun), "*" or "daily".
+// Times are interpreted in loc.
+func ParseSchedule(specs []string, loc *time.Location) (*Schedule, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	s := &Schedule{loc: loc}
+	for _, spec := range specs {
+		w, err := parseWindow(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %v", spec, err)
+		}
+		s.windows = append(s.windows, w)
+	}
+	if len(s.windows) == 0 {
+		return nil, fmt.Errorf("schedule is empty")
+	}
+	return s, nil
+}
+
+func parseWindow(spec string) (window, error) {
+	w := window{definition: strings.TrimSpace(spec)}
+
+	fields := strings.Fields(spec)
+	var daySpec, timeSpec string
+	switch len(fields) {
+	case 1:
+		daySpec, timeSpec = "*", fields[0]
+	case 2:
+		daySpec, timeSpec = fields[0], fields[1]
+	default:
+		return w, fmt.Errorf("expected [days] HH:MM-HH:MM")
+	}
+
+	days, err := parseDays(daySpec)
+	if err != nil {
+		return w, err
+	}
+	w.days = days
+
+	start, end, ok := strings.Cut(timeSpec, "-")
+	if !ok {
+		return w, fmt.Errorf("expected a time range HH:MM-HH:MM")
+	}
+	if w.startHour, w.startMin, err = parseClock(start); err != nil {
+		return w, err
+	}
+	if w.endHour, w.endMin, err = parseClock(end); err != nil {
+		return w, err
+	}
+	if w.startHour == 24 {
+		return w, fmt.Errorf("window cannot start at 24:00")
+	}
+
+	startMins, endMins := w.startHour*60+w.startMin, w.endHour*60+w.endMin
+	if startMins == endMins {
+		return w, fmt.Errorf("window is empty")
+	}
+	w.overnight = endMins < startMins
+
+	return w, nil
+}
+
+func parseDays(spec string) ([7]bool, error) {
+	var days [7]bool
+
+	if spec == "*" || strings.EqualFold(spec, "daily") {
+		for i := range days {
+			days[i] = true
+		}
+		return days, nil
+	}
+
+	for _, part := range strings.Split(spec, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+
+		first, ok := weekdays[strings.ToLower(from)]
+		if !ok {
+			return days, fmt.Errorf("unknown day %q", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdays[strings.ToLower(to)]; !ok {
+				return days, fmt.Errorf("unknown day %q", to)
+			}
+		}
+
+		// ranges may wrap around the week, e.g. Fri-Mon
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+func parseClock(input string) (int, int, error) {
+	h, m, ok := strings.Cut(input, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid time %q, expected HH:MM", input)
+	}
+	hour, err := strconv.Atoi(h)
+	if err != nil || hour < 0 || hour > 24 {
+		return 0, 0, fmt.Errorf("invalid hour in %q", input)
+	}
+	minute, err := strconv.Atoi(m)
+	if err != nil || minute < 0 || minute > 59 || (hour == 24 && minute != 0) {
+		return 0, 0, fmt.Errorf("invalid minute in %q", input)
+	}
+	return hour, minute, nil
+}
+
+// bounds returns the window occurrence that starts on the given day.
+func (w window) bounds(day time.Time, loc *time.Location) (time.Time, time.Time) {
+	y, mo, d := day.Date()
+	start := time.Date(y, mo, d, w.startHour, w.startMin, 0, 0, loc)
+	if w.overnight {
+		d++
+	}
+	return start, time.Date(y, mo, d, w.endHour, w.endMin, 0, 0, loc)
+}
+
+// Active reports whether t falls inside any mining window.
+func (s *Schedule) Active(t time.Time) bool {
+	t = t.In(s.loc)
+	for _, w := range s.windows {
+		// a window that started yesterday may still be running
+		for _, offset := range []int{0, -1} {
+			day := t.AddDate(0, 0, offset)
+			if !w.days[day.Weekday()] {
+				continue
+			}
+			start, end := w.bounds(day, s.loc)
+			if !t.Before(start) && t.Before(end) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NextChange returns the next time after t at which Active flips.
+func (s *Schedule) NextChange(t time.Time) time.Time {
+	t = t.In(s.loc)
+	active := s.Active(t)
+
+	var boundaries []time.Time
+	for _, w := range s.windows {
+		for offset := -1; offset <= 8; offset++ {
+			day := t.AddDate(0, 0, offset)
+			if !w.days[day.Weekday()] {
+				continue
+			}
+			start, end := w.bounds(day, s.loc)
+			boundaries = append(boundaries, start, end)
+		}
+	}
+	sort.Slice(boundaries, func(i, j int) bool { return boundaries[i].Before(boundaries[j]) })
+
+	for _, b := range boundaries {
+		if b.After(t) && s.Active(b) != active {
+			return b
+		}
+	}
+	// every day is fully covered, nothing will ever change
+	return t.AddDate(0, 0, 7)
+}
+
+func (s *Schedule) String() string {
+	defs := make([]string, len(s.windows))
+	for i, w := range s.windows {
+		defs[i] = w.definition
+	}
+	return strings.Join(defs, ", ")
+}
+
+// runSchedule pauses and resumes the miner at the schedule boundaries.
+func (m *Miner) runSchedule(ctx context.Context, s *Schedule) {
+	m.logger.Info().Msgf("🕑 mining schedule: %s", s)
+
+	for {
+		now := m.clock.Now()
+		if s.Active(now) {
+			m.Resume(pauseReasonSchedule)
+		} else {
+			m.Pause(pauseReasonSchedule)
+		}
+
+		next := s.NextChange(now)
+		m.logger.Info().Msgf("🕑 next schedule change at %s", next.Format(time.RFC1123))
+
+		select {
+		case <-m.clock.After(next.Sub(now)):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package mining
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/flokiorg/grpc-miner/common"
+	"github.com/flokiorg/grpc-miner/utils"
+	"github.com/rs/zerolog/log"
+)
+
+// 2024-01-01 is a Monday
+func at(day int, hour int, min int) time.Time {
+	return time.Date(2024, 1, day, hour, min, 0, 0, time.UTC)
+}
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		spec  string
+		valid bool
+	}{
+		{"Mon-Fri 22:00-06:00", true},
+		{"Sat,Sun 00:00-24:00", true},
+		{"Fri-Mon 01:30-02:00", true},
+		{"daily 12:00-13:00", true},
+		{"12:00-13:00", true},
+		{"Mon-Fri", false},
+		{"Funday 10:00-11:00", false},
+		{"Mon 10:00-10:00", false},
+		{"Mon 25:00-01:00", false},
+		{"Mon 10:60-11:00", false},
+		{"Mon 24:00-01:00", false},
+		{"Mon 10:00 11:00 12:00", false},
+	}
+
+	for _, test := range tests {
+		_, err := ParseSchedule([]string{test.spec}, time.UTC)
+		if (err == nil) != test.valid {
+			t.Fatalf("%q: unexpected parse result, valid=%v err=%v", test.spec, test.valid, err)
+		}
+	}
+
+	if _, err := ParseSchedule(nil, time.UTC); err == nil {
+		t.Fatal("expected an error for an empty schedule")
+	}
+}
+
+func TestScheduleActive(t *testing.T) {
+	schedule, err := ParseSchedule([]string{"Mon-Fri 22:00-06:00", "Sun 10:00-12:00"}, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		t      time.Time
+		active bool
+	}{
+		{"monday before the window", at(1, 21, 59), false},
+		{"monday window start", at(1, 22, 0), true},
+		{"overnight into tuesday", at(2, 5, 59), true},
+		{"tuesday window end", at(2, 6, 0), false},
+		{"friday night into saturday", at(6, 3, 0), true},
+		{"saturday night", at(6, 23, 0), false},
+		{"sunday window", at(7, 11, 0), true},
+		{"sunday night", at(7, 23, 0), false},
+		{"monday morning after sunday", at(8, 1, 0), false},
+	}
+
+	for _, test := range tests {
+		if got := schedule.Active(test.t); got != test.active {
+			t.Fatalf("%s: want=%v got=%v", test.name, test.active, got)
+		}
+	}
+
+	if next := schedule.NextChange(at(5, 12, 0)); !next.Equal(at(5, 22, 0)) {
+		t.Fatalf("unexpected next change from friday noon: %s", next)
+	}
+	if next := schedule.NextChange(at(6, 1, 0)); !next.Equal(at(6, 6, 0)) {
+		t.Fatalf("unexpected next change from saturday night: %s", next)
+	}
+	if next := schedule.NextChange(at(6, 6, 0)); !next.Equal(at(7, 10, 0)) {
+		t.Fatalf("unexpected next change from saturday morning: %s", next)
+	}
+}
+
+func TestRunSchedule(t *testing.T) {
+	schedule, err := ParseSchedule([]string{"Mon-Fri 22:00-06:00"}, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock := utils.NewFakeClock(at(1, 12, 0))
+	miner := NewMiner(&common.Config{}, nil, nil, log.Logger)
+	miner.clock = clock
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go miner.runSchedule(ctx, schedule)
+
+	clock.BlockUntil(1)
+	if !miner.Paused() {
+		t.Fatal("expected mining to be paused outside the window")
+	}
+
+	clock.Advance(time.Hour * 10)
+	clock.BlockUntil(1)
+	if miner.Paused() {
+		t.Fatal("expected mining to resume at the window start")
+	}
+
+	clock.Advance(time.Hour * 8)
+	clock.BlockUntil(1)
+	if !miner.Paused() {
+		t.Fatal("expected mining to pause at the window end")
+	}
+}
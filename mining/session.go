@@ -0,0 +1,240 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package mining
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/flokiorg/grpc-miner/mining/pb"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+var errSessionClosed = status.Error(codes.Unavailable, "session closed")
+
+// redirect is returned by runSession when the pool asks the miner to move.
+type redirect struct {
+	address string
+	delay   time.Duration
+}
+
+func (r *redirect) Error() string {
+	return fmt.Sprintf("pool redirected to %s", r.address)
+}
+
+// session multiplexes jobs, solutions and their acks over a single Session
+// stream. Acks are matched to solutions by id.
+type session struct {
+	stream pb.CandidateStream_SessionClient
+	sendMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[uint64]chan *pb.Ack
+	done    chan struct{}
+}
+
+func (s *session) send(msg *pb.MinerMessage) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	return s.stream.Send(msg)
+}
+
+// close fails every solution still waiting for an ack.
+func (s *session) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	close(s.done)
+	s.pending = nil
+}
+
+func (s *session) resolve(ack *pb.Ack) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ch, ok := s.pending[ack.Id]; ok {
+		delete(s.pending, ack.Id)
+		ch <- ack
+	}
+}
+
+// runSession opens a Session, requests work and dispatches pool messages until
+// the stream breaks.
+func (c *Client) runSession(ctx context.Context, request *pb.CandidateRequest, blocks chan<- *pb.CandidateBlock) (bool, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	rpc, opts := c.rpc()
+	stream, err := rpc.Session(ctx, opts...)
+	if err != nil {
+		return false, fmt.Errorf("session open failed: %w", err)
+	}
+
+	s := &session{
+		stream:  stream,
+		pending: make(map[uint64]chan *pb.Ack),
+		done:    make(chan struct{}),
+	}
+	c.sessionMu.Lock()
+	if c.resumeToken != "" {
+		request = proto.Clone(request).(*pb.CandidateRequest)
+		request.ResumeToken = c.resumeToken
+	}
+	c.sessionMu.Unlock()
+
+	if err := s.send(&pb.MinerMessage{Payload: &pb.MinerMessage_Request{Request: request}}); err != nil {
+		return false, fmt.Errorf("session request failed: %w", err)
+	}
+
+	c.sessionMu.Lock()
+	c.session = s
+	close(c.sessionUp)
+	c.sessionMu.Unlock()
+
+	defer func() {
+		c.sessionMu.Lock()
+		if c.session == s {
+			c.session = nil
+			c.sessionUp = make(chan struct{})
+		}
+		c.sessionMu.Unlock()
+		s.close()
+	}()
+
+	log.Info().Msg("Session opened, listening for candidate blocks...")
+
+	live := c.watchdog(ctx, cancel, func() error {
+		ping := &pb.Keepalive{Timestamp: time.Now().UnixMilli()}
+		return s.send(&pb.MinerMessage{Payload: &pb.MinerMessage_Keepalive{Keepalive: ping}})
+	})
+
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			if cause := context.Cause(ctx); errors.Is(cause, errStreamSilent) {
+				return true, cause
+			}
+			return true, err
+		}
+		live.touch()
+
+		switch payload := msg.Payload.(type) {
+		case *pb.PoolMessage_Job:
+			if !c.deliverBlock(ctx, payload.Job, blocks, live) {
+				return true, ctx.Err()
+			}
+
+		case *pb.PoolMessage_Ack:
+			s.resolve(payload.Ack)
+
+		case *pb.PoolMessage_Keepalive:
+			if payload.Keepalive.Reply {
+				continue
+			}
+			reply := &pb.Keepalive{Timestamp: payload.Keepalive.Timestamp, Reply: true}
+			if err := s.send(&pb.MinerMessage{Payload: &pb.MinerMessage_Keepalive{Keepalive: reply}}); err != nil {
+				return true, err
+			}
+
+		case *pb.PoolMessage_Resume:
+			c.sessionMu.Lock()
+			c.resumeToken = payload.Resume.Token
+			c.sessionMu.Unlock()
+			if payload.Resume.Resumed {
+				log.Info().Msg("Session resumed, earlier jobs remain valid")
+			}
+			c.reconnected(payload.Resume.Resumed)
+
+		case *pb.PoolMessage_Notice:
+			log.Warn().Str("notice", payload.Notice.Message).Msg("Pool notice")
+
+		case *pb.PoolMessage_Maintenance:
+			var until time.Time
+			event := log.Warn().Str("message", payload.Maintenance.Message)
+			if payload.Maintenance.Until > 0 {
+				until = time.Unix(payload.Maintenance.Until, 0)
+				event = event.Time("until", until)
+			}
+			event.Msg("Pool entering maintenance")
+			c.setHealth(pb.HealthStatus_MAINTENANCE, payload.Maintenance.Message, until)
+
+		case *pb.PoolMessage_Reconnect:
+			return true, &redirect{
+				address: payload.Reconnect.Address,
+				delay:   time.Duration(payload.Reconnect.DelaySeconds) * time.Second,
+			}
+		}
+	}
+}
+
+// submitOverSession sends a solution on the current session and waits for its
+// ack. Rejections carry the status code chosen by the pool.
+func (c *Client) submitOverSession(ctx context.Context, solution *pb.Solution) (*pb.AckBlockSubmited, error) {
+	c.sessionMu.Lock()
+	s := c.session
+	c.sessionMu.Unlock()
+	if s == nil {
+		return nil, errSessionClosed
+	}
+
+	id := c.nextID.Add(1)
+	solution.Id = id
+	ch := make(chan *pb.Ack, 1)
+
+	s.mu.Lock()
+	if s.pending == nil {
+		s.mu.Unlock()
+		return nil, errSessionClosed
+	}
+	s.pending[id] = ch
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+	}()
+
+	if err := s.send(&pb.MinerMessage{Payload: &pb.MinerMessage_Solution{Solution: solution}}); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "session send failed: %v", err)
+	}
+
+	select {
+	case ack := <-ch:
+		if !ack.Accepted {
+			code := codes.Code(ack.Code)
+			if code == codes.OK {
+				code = codes.Unknown
+			}
+			return nil, status.Error(code, ack.Reason)
+		}
+		return &pb.AckBlockSubmited{Header: ack.Header}, nil
+	case <-s.done:
+		return nil, errSessionClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// sessionDown returns a channel closed once a session is running, or nil when
+// one is running already or the pool does not support sessions.
+func (c *Client) sessionDown() <-chan struct{} {
+	if !c.Supports(pb.Feature_FEATURE_SESSION) {
+		return nil
+	}
+
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+	if c.session != nil {
+		return nil
+	}
+	return c.sessionUp
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package sim
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	. "github.com/flokiorg/grpc-miner/mining/algo/common"
+	"github.com/flokiorg/grpc-miner/mining/pb"
+	"github.com/flokiorg/grpc-miner/utils"
+)
+
+// Attempt is a call to Mine.
+type Attempt struct {
+	Block  *pb.CandidateBlock
+	Thread uint8
+	Range  utils.MinMax
+}
+
+// Algo is a MinerAlgo that finds the nonce set for a job as soon as it is
+// in the range mined, and nothing otherwise: without a solution it mines
+// until cancelled, like on an unreachable target, or until EndChunk.
+type Algo struct {
+	mu        sync.Mutex
+	solutions map[string]uint32
+	chunkEnd  chan struct{}
+	attempts  *record[Attempt]
+}
+
+func NewAlgo() *Algo {
+	return &Algo{
+		solutions: make(map[string]uint32),
+		chunkEnd:  make(chan struct{}),
+		attempts:  newRecord[Attempt](),
+	}
+}
+
+// EndChunk makes the calls to Mine in progress return as if they went
+// through their range without finding a solution.
+func (a *Algo) EndChunk() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	close(a.chunkEnd)
+	a.chunkEnd = make(chan struct{})
+}
+
+// Solve makes nonce the solution of the job, see JobKey.
+func (a *Algo) Solve(job string, nonce uint32) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.solutions[job] = nonce
+}
+
+// JobKey identifies a job by its id, or by its header for pools without job ids.
+func JobKey(block *pb.CandidateBlock) string {
+	if block.JobId != "" {
+		return block.JobId
+	}
+	return block.Header
+}
+
+func (a *Algo) Mine(ctx context.Context, stats *Stats, block *pb.CandidateBlock, nonceRange utils.MinMax, tid uint8) (string, uint32, error) {
+	a.mu.Lock()
+	nonce, ok := a.solutions[JobKey(block)]
+	chunkEnd := a.chunkEnd
+	a.mu.Unlock()
+	a.attempts.add(Attempt{Block: block, Thread: tid, Range: nonceRange})
+
+	if ok && nonce >= nonceRange.Min && nonce <= nonceRange.Max {
+		return fmt.Sprintf("%064x", nonce), nonce, nil
+	}
+	select {
+	case <-ctx.Done():
+		return "", 0, ErrMiningCancelled
+	case <-chunkEnd:
+		return "", 0, ErrMiningCompleted
+	}
+}
+
+// Attempts returns the calls to Mine so far.
+func (a *Algo) Attempts() []Attempt {
+	return a.attempts.all()
+}
+
+// WaitAttempts returns the calls to Mine once there were at least n.
+func (a *Algo) WaitAttempts(n int, timeout time.Duration) ([]Attempt, error) {
+	return a.attempts.wait(n, timeout)
+}
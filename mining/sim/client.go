@@ -0,0 +1,117 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package sim
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/flokiorg/grpc-miner/mining"
+	"github.com/flokiorg/grpc-miner/mining/pb"
+)
+
+// Client is an in-process mining.PoolClient calling the Pool directly, with
+// neither a network nor delays between reconnections and retries.
+type Client struct {
+	pool   *Pool
+	events *mining.Bus
+
+	mu      sync.Mutex
+	request *pb.CandidateRequest
+	restart context.CancelFunc
+}
+
+func NewClient(pool *Pool) *Client {
+	return &Client{pool: pool}
+}
+
+// SetEvents makes the client report the streams the pool failed to open.
+func (c *Client) SetEvents(events *mining.Bus) {
+	c.events = events
+}
+
+// Listen reopens the stream right away whenever it ends, the pool failing to
+// open one being reported as the pool not serving.
+func (c *Client) Listen(ctx context.Context, request *pb.CandidateRequest, blocks chan<- *pb.CandidateBlock) {
+	c.mu.Lock()
+	c.request = request
+	c.mu.Unlock()
+
+	for ctx.Err() == nil {
+		streamCtx, restart := context.WithCancel(ctx)
+		c.mu.Lock()
+		request, c.restart = c.request, restart
+		c.mu.Unlock()
+
+		templates, err := c.pool.Templates(streamCtx, request)
+		if err != nil {
+			c.events.Publish(mining.HealthChanged{Time: time.Now(), Status: pb.HealthStatus_NOT_SERVING, Message: err.Error()})
+			restart()
+			continue
+		}
+		c.forward(streamCtx, templates, blocks)
+		restart()
+	}
+}
+
+// forward relays templates until the stream is dropped or restarted.
+func (c *Client) forward(ctx context.Context, templates <-chan *pb.CandidateBlock, blocks chan<- *pb.CandidateBlock) {
+	for {
+		select {
+		case block, ok := <-templates:
+			if !ok {
+				return
+			}
+			select {
+			case blocks <- block:
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *Client) Renew(request *pb.CandidateRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.request = request
+	if c.restart != nil {
+		c.restart()
+	}
+}
+
+func (c *Client) SubmitNonce(ctx context.Context, block *pb.CandidateBlock, nonce uint32, maxRetries int, maxBackoffSeconds float64) (*pb.AckBlockSubmited, error) {
+	for attempt := 0; ; attempt++ {
+		ack, err := c.pool.Submit(ctx, block, nonce)
+		if err == nil || attempt >= maxRetries || ctx.Err() != nil {
+			return ack, err
+		}
+	}
+}
+
+func (c *Client) Generate(ctx context.Context, blocks int) ([]string, error) {
+	return c.pool.Generate(ctx, blocks)
+}
+
+func (c *Client) BlockStatus(ctx context.Context, hashes []string) ([]*pb.BlockInfo, error) {
+	return nil, mining.ErrBlockStatusUnsupported
+}
+
+func (c *Client) Health(ctx context.Context) (pb.HealthStatus, error) {
+	return pb.HealthStatus_SERVING, nil
+}
+
+func (c *Client) Supports(feature pb.Feature) bool {
+	return slices.Contains(c.pool.Server.Features, feature)
+}
+
+func (c *Client) Close() {}
+
+var _ mining.PoolClient = (*Client)(nil)
@@ -0,0 +1,176 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package sim
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/flokiorg/grpc-miner/mining/pb"
+	"github.com/flokiorg/grpc-miner/pool"
+)
+
+// Submission is a solution received by the Pool, Err is what it was failed with.
+type Submission struct {
+	Block *pb.CandidateBlock
+	Nonce uint32
+	Err   error
+}
+
+// Pool is a pool.MemoryBackend with injected faults, served by the reference
+// pool so miners reach it through their real client. It does not report block
+// status, like most pools.
+type Pool struct {
+	Server *pool.Server
+
+	memory *pool.MemoryBackend
+
+	mu               sync.Mutex
+	streams          map[int]context.CancelFunc
+	nextStream       int
+	templateFailures []error
+	submitFailures   []error
+
+	opened      *record[*pb.CandidateRequest]
+	submissions *record[Submission]
+}
+
+func NewPool() *Pool {
+	p := &Pool{
+		memory:      pool.NewMemoryBackend(),
+		streams:     make(map[int]context.CancelFunc),
+		opened:      newRecord[*pb.CandidateRequest](),
+		submissions: newRecord[Submission](),
+	}
+	p.Server = pool.NewServer(p)
+	return p
+}
+
+// Start serves the pool on a loopback port, it returns the address and a stop function.
+func (p *Pool) Start() (string, func(), error) {
+	return p.Server.ServeLocal()
+}
+
+// Push makes block the current template and sends it to every open stream,
+// pushing the same block twice sends a duplicate.
+func (p *Pool) Push(block *pb.CandidateBlock) {
+	p.memory.Push(block)
+}
+
+// Drop ends every open stream, as a pool losing its template source would.
+func (p *Pool) Drop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for id, drop := range p.streams {
+		drop()
+		delete(p.streams, id)
+	}
+}
+
+// FailTemplates fails the next streams opened with errs, one each, before
+// opening them again.
+func (p *Pool) FailTemplates(errs ...error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.templateFailures = append(p.templateFailures, errs...)
+}
+
+// FailSubmits fails the next submissions with errs, one each, before
+// accepting them again.
+func (p *Pool) FailSubmits(errs ...error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.submitFailures = append(p.submitFailures, errs...)
+}
+
+// nextFailure pops the first of failures, nil when there is none left.
+func (p *Pool) nextFailure(failures *[]error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(*failures) == 0 {
+		return nil
+	}
+	err := (*failures)[0]
+	*failures = (*failures)[1:]
+	return err
+}
+
+func (p *Pool) Templates(ctx context.Context, request *pb.CandidateRequest) (<-chan *pb.CandidateBlock, error) {
+	if err := p.nextFailure(&p.templateFailures); err != nil {
+		return nil, err
+	}
+
+	streamCtx, drop := context.WithCancel(ctx)
+	templates, err := p.memory.Templates(streamCtx, request)
+	if err != nil {
+		drop()
+		return nil, err
+	}
+
+	p.mu.Lock()
+	id := p.nextStream
+	p.nextStream++
+	p.streams[id] = drop
+	p.mu.Unlock()
+	p.opened.add(request)
+
+	// relayed so that a dropped stream is closed, keeping the latest template only
+	stream := make(chan *pb.CandidateBlock, 1)
+	go func() {
+		defer func() {
+			p.mu.Lock()
+			delete(p.streams, id)
+			p.mu.Unlock()
+			drop()
+			close(stream)
+		}()
+		for {
+			select {
+			case block := <-templates:
+				select {
+				case <-stream:
+				default:
+				}
+				stream <- block
+			case <-streamCtx.Done():
+				return
+			}
+		}
+	}()
+	return stream, nil
+}
+
+func (p *Pool) Submit(ctx context.Context, block *pb.CandidateBlock, nonce uint32) (*pb.AckBlockSubmited, error) {
+	if err := p.nextFailure(&p.submitFailures); err != nil {
+		p.submissions.add(Submission{Block: block, Nonce: nonce, Err: err})
+		return nil, err
+	}
+
+	ack, err := p.memory.Submit(ctx, block, nonce)
+	p.submissions.add(Submission{Block: block, Nonce: nonce, Err: err})
+	return ack, err
+}
+
+func (p *Pool) Generate(ctx context.Context, numBlocks int) ([]string, error) {
+	return p.memory.Generate(ctx, numBlocks)
+}
+
+// Submissions returns the solutions received so far, failed ones included.
+func (p *Pool) Submissions() []Submission {
+	return p.submissions.all()
+}
+
+// WaitSubmissions returns the solutions received once there were at least n.
+func (p *Pool) WaitSubmissions(n int, timeout time.Duration) ([]Submission, error) {
+	return p.submissions.wait(n, timeout)
+}
+
+// WaitStreams returns the requests of the streams opened once there were at least n.
+func (p *Pool) WaitStreams(n int, timeout time.Duration) ([]*pb.CandidateRequest, error) {
+	return p.opened.wait(n, timeout)
+}
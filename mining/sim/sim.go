@@ -0,0 +1,88 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+// Package sim simulates what a Miner talks to, so its lifecycle can be tested
+// quickly and deterministically: an Algo that solves jobs at chosen nonces
+// only, and a Pool replaying scripted templates with injected faults. Time is
+// simulated with utils.FakeClock.
+package sim
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/flokiorg/go-flokicoin/chaincfg/chainhash"
+	"github.com/flokiorg/go-flokicoin/wire"
+	"github.com/flokiorg/grpc-miner/mining/pb"
+)
+
+// Template returns a clean job at height, building on a parent derived from
+// the height so that every height has its own header. Its job id is the height.
+func Template(height int64) *pb.CandidateBlock {
+	parent := chainhash.DoubleHashH(strconv.AppendInt(nil, height-1, 10))
+	msgBlock := wire.NewMsgBlock(wire.NewBlockHeader(0x20000000, &parent, &chainhash.Hash{}, 0x207fffff, 0))
+	msgBlock.Header.Timestamp = time.Unix(1700000000+height*60, 0)
+
+	var header, block bytes.Buffer
+	msgBlock.Header.Serialize(&header)
+	msgBlock.Serialize(&block)
+
+	return &pb.CandidateBlock{
+		Bits:      "207fffff",
+		Header:    hex.EncodeToString(header.Bytes()),
+		Height:    height,
+		Block:     block.Bytes(),
+		Version:   0x20000000,
+		JobId:     strconv.FormatInt(height, 10),
+		CleanJobs: true,
+	}
+}
+
+// record is an append-only list that can be waited on.
+type record[T any] struct {
+	mu      sync.Mutex
+	items   []T
+	changed chan struct{}
+}
+
+func newRecord[T any]() *record[T] {
+	return &record[T]{changed: make(chan struct{})}
+}
+
+func (r *record[T]) add(item T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.items = append(r.items, item)
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+func (r *record[T]) all() []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]T(nil), r.items...)
+}
+
+// wait returns the items once there are at least n of them.
+func (r *record[T]) wait(n int, timeout time.Duration) ([]T, error) {
+	deadline := time.After(timeout)
+	for {
+		r.mu.Lock()
+		items, changed := append([]T(nil), r.items...), r.changed
+		r.mu.Unlock()
+
+		if len(items) >= n {
+			return items, nil
+		}
+		select {
+		case <-changed:
+		case <-deadline:
+			return items, fmt.Errorf("got %d of %d after %s", len(items), n, timeout)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package mining
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/bits"
+	"slices"
+	"strings"
+
+	"github.com/flokiorg/go-flokicoin/blockchain"
+	"github.com/flokiorg/go-flokicoin/chaincfg"
+	"github.com/flokiorg/go-flokicoin/chainutil"
+	"github.com/flokiorg/go-flokicoin/txscript"
+	"github.com/flokiorg/go-flokicoin/wire"
+	"github.com/flokiorg/grpc-miner/common"
+	"github.com/flokiorg/grpc-miner/mining/pb"
+)
+
+const (
+	VerifyStrict = "strict"
+	VerifyWarn   = "warn"
+	VerifyOff    = "off"
+)
+
+var ErrUntrustedTemplate = errors.New("template does not match what was requested")
+
+// VerifyTemplate checks that block is what its fields claim and that mining it
+// pays us: the header and merkle root match the transactions, and every
+// output of the coinbase carrying value pays one of the requested addresses,
+// each weighted payout receiving at least its share of the reward. The
+// coinbase must also hold the requested coinbase script.
+func VerifyTemplate(block *pb.CandidateBlock, request *pb.CandidateRequest, params *chaincfg.Params) error {
+	var msgBlock wire.MsgBlock
+	if err := msgBlock.Deserialize(bytes.NewReader(block.Block)); err != nil {
+		return fmt.Errorf("%w: malformed block: %v", ErrUntrustedTemplate, err)
+	}
+
+	var header bytes.Buffer
+	if err := msgBlock.Header.Serialize(&header); err != nil {
+		return err
+	}
+	if hex.EncodeToString(header.Bytes()) != strings.ToLower(block.Header) {
+		return fmt.Errorf("%w: header does not match the block", ErrUntrustedTemplate)
+	}
+	if bits := fmt.Sprintf("%08x", msgBlock.Header.Bits); bits != strings.ToLower(block.Bits) {
+		return fmt.Errorf("%w: bits %s do not match the header's %s", ErrUntrustedTemplate, block.Bits, bits)
+	}
+
+	if len(msgBlock.Transactions) == 0 || !blockchain.IsCoinBaseTx(msgBlock.Transactions[0]) {
+		return fmt.Errorf("%w: block has no coinbase", ErrUntrustedTemplate)
+	}
+	txs := make([]*chainutil.Tx, 0, len(msgBlock.Transactions))
+	for _, tx := range msgBlock.Transactions {
+		txs = append(txs, chainutil.NewTx(tx))
+	}
+	merkleRoot := blockchain.CalcMerkleRoot(txs, false)
+	if merkleRoot != msgBlock.Header.MerkleRoot {
+		return fmt.Errorf("%w: merkle root %s does not match the transactions", ErrUntrustedTemplate, msgBlock.Header.MerkleRoot)
+	}
+	if block.Merkleroot != "" && block.Merkleroot != merkleRoot.String() {
+		return fmt.Errorf("%w: claimed merkle root %s is not %s", ErrUntrustedTemplate, block.Merkleroot, merkleRoot)
+	}
+
+	coinbase := msgBlock.Transactions[0]
+	var paid int64
+	for _, out := range coinbase.TxOut {
+		paid += out.Value
+	}
+	if block.Amount != 0 && block.Amount != paid {
+		return fmt.Errorf("%w: claimed amount %d but the coinbase pays %d", ErrUntrustedTemplate, block.Amount, paid)
+	}
+
+	if ours := requestedAddresses(request); len(ours) > 0 {
+		if block.Address != "" && !slices.Contains(ours, block.Address) {
+			return fmt.Errorf("%w: template is for %s, not one of our addresses", ErrUntrustedTemplate, block.Address)
+		}
+		for i, out := range coinbase.TxOut {
+			if out.Value == 0 {
+				continue // witness commitment and other data carriers
+			}
+			_, addrs, _, err := txscript.ExtractPkScriptAddrs(out.PkScript, params)
+			if err != nil || len(addrs) != 1 || !slices.Contains(ours, addrs[0].EncodeAddress()) {
+				return fmt.Errorf("%w: coinbase output %d pays %d to %s", ErrUntrustedTemplate, i, out.Value, describePayee(addrs, out.PkScript))
+			}
+		}
+		if err := verifySplit(coinbase, request.GetPayouts(), params); err != nil {
+			return err
+		}
+	}
+
+	if cbs := request.GetCoinbaseScript(); cbs != nil {
+		text, err := common.ExpandCoinbaseText(cbs.Text, common.CoinbaseVars{
+			Worker: request.GetWorker().GetName(),
+			Rig:    request.GetWorker().GetRigId(),
+			Height: block.Height,
+			Time:   msgBlock.Header.Timestamp,
+		})
+		if err != nil {
+			return err
+		}
+		if !bytes.Contains(coinbase.TxIn[0].SignatureScript, text) {
+			return fmt.Errorf("%w: coinbase misses our coinbase script %q", ErrUntrustedTemplate, text)
+		}
+	}
+	return nil
+}
+
+// verifySplit checks that every weighted payout is paid at least its share of
+// the coinbase, value*weight/total rounded down as pools split it; the rounding
+// remainder may go to any of them.
+func verifySplit(coinbase *wire.MsgTx, payouts []*pb.Payout, params *chaincfg.Params) error {
+	var reward, total int64
+	paid := make(map[string]int64)
+	for _, out := range coinbase.TxOut {
+		reward += out.Value
+		if _, addrs, _, err := txscript.ExtractPkScriptAddrs(out.PkScript, params); err == nil && len(addrs) == 1 {
+			paid[addrs[0].EncodeAddress()] += out.Value
+		}
+	}
+
+	weights := make(map[string]int64)
+	for _, payout := range payouts {
+		weights[payout.Address] += int64(payout.Weight)
+		total += int64(payout.Weight)
+	}
+	if total == 0 {
+		return nil
+	}
+
+	for _, payout := range payouts {
+		if want := weightedShare(reward, weights[payout.Address], total); paid[payout.Address] < want {
+			return fmt.Errorf("%w: coinbase pays %s %d, less than its share %d", ErrUntrustedTemplate, payout.Address, paid[payout.Address], want)
+		}
+	}
+	return nil
+}
+
+// weightedShare returns value*weight/total rounded down, without overflowing.
+func weightedShare(value, weight, total int64) int64 {
+	hi, lo := bits.Mul64(uint64(value), uint64(weight))
+	quo, _ := bits.Div64(hi, lo, uint64(total))
+	return int64(quo)
+}
+
+// requestedAddresses returns the addresses the pool was asked to pay.
+func requestedAddresses(request *pb.CandidateRequest) []string {
+	addrs := slices.Clone(request.GetMiningAddrs())
+	for _, payout := range request.GetPayouts() {
+		if !slices.Contains(addrs, payout.Address) {
+			addrs = append(addrs, payout.Address)
+		}
+	}
+	return addrs
+}
+
+func describePayee(addrs []chainutil.Address, pkScript []byte) string {
+	if len(addrs) == 1 {
+		return addrs[0].EncodeAddress()
+	}
+	return "script " + hex.EncodeToString(pkScript)
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package mining
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/flokiorg/go-flokicoin/blockchain"
+	"github.com/flokiorg/go-flokicoin/chaincfg"
+	"github.com/flokiorg/go-flokicoin/chaincfg/chainhash"
+	"github.com/flokiorg/go-flokicoin/chainutil"
+	"github.com/flokiorg/go-flokicoin/txscript"
+	"github.com/flokiorg/go-flokicoin/wire"
+	"github.com/flokiorg/grpc-miner/mining/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// buildTemplate returns a template at height 100 whose coinbase pays value to each of payees.
+func buildTemplate(t *testing.T, script []byte, value int64, payees ...chainutil.Address) *pb.CandidateBlock {
+	t.Helper()
+	values := make([]int64, len(payees))
+	for i := range values {
+		values[i] = value
+	}
+	return buildSplitTemplate(t, script, values, payees)
+}
+
+// buildSplitTemplate returns a template at height 100 whose coinbase pays values[i] to payees[i].
+func buildSplitTemplate(t *testing.T, script []byte, values []int64, payees []chainutil.Address) *pb.CandidateBlock {
+	t.Helper()
+
+	var amount int64
+	coinbase := wire.NewMsgTx(wire.TxVersion)
+	coinbase.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex), script, nil))
+	for i, payee := range payees {
+		pkScript, err := txscript.PayToAddrScript(payee)
+		if err != nil {
+			t.Fatal(err)
+		}
+		coinbase.AddTxOut(wire.NewTxOut(values[i], pkScript))
+		amount += values[i]
+	}
+	coinbase.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_RETURN, 0x01, 0x00}))
+
+	merkleRoot := blockchain.CalcMerkleRoot([]*chainutil.Tx{chainutil.NewTx(coinbase)}, false)
+	msgBlock := wire.NewMsgBlock(wire.NewBlockHeader(0x20000000, &chainhash.Hash{1}, &merkleRoot, 0x207fffff, 0))
+	msgBlock.Header.Timestamp = time.Unix(1700000000, 0)
+	msgBlock.AddTransaction(coinbase)
+
+	var header, block bytes.Buffer
+	msgBlock.Header.Serialize(&header)
+	msgBlock.Serialize(&block)
+
+	return &pb.CandidateBlock{
+		Bits:       "207fffff",
+		Header:     hex.EncodeToString(header.Bytes()),
+		Height:     100,
+		Merkleroot: merkleRoot.String(),
+		Amount:     amount,
+		Block:      block.Bytes(),
+		Address:    payees[0].EncodeAddress(),
+	}
+}
+
+func TestVerifyTemplate(t *testing.T) {
+	params := &chaincfg.TestNet3Params
+	ours, _ := chainutil.NewAddressPubKeyHash(bytesOf(1), params)
+	theirs, _ := chainutil.NewAddressPubKeyHash(bytesOf(2), params)
+
+	request := &pb.CandidateRequest{
+		MiningAddrs:    []string{ours.EncodeAddress()},
+		CoinbaseScript: &pb.CoinbaseScript{BytesLeft: 2, Text: "/{worker}/{height}/"},
+		Worker:         &pb.Worker{Name: "rig-01"},
+	}
+	script := []byte("\x01\x64ab/rig-01/100/")
+
+	tests := []struct {
+		name   string
+		block  *pb.CandidateBlock
+		tamper func(*pb.CandidateBlock)
+		fail   bool
+	}{
+		{"genuine", buildTemplate(t, script, 5000, ours), nil, false},
+		{"split among us", buildTemplate(t, script, 5000, ours, ours), nil, false},
+		{"pays someone else", buildTemplate(t, script, 5000, theirs), nil, true},
+		{"pool fee", buildTemplate(t, script, 5000, ours, theirs), nil, true},
+		{"no coinbase script", buildTemplate(t, []byte("\x01\x64/pool/"), 5000, ours), nil, true},
+		{"claimed address", buildTemplate(t, script, 5000, ours), func(b *pb.CandidateBlock) { b.Address = theirs.EncodeAddress() }, true},
+		{"claimed amount", buildTemplate(t, script, 5000, ours), func(b *pb.CandidateBlock) { b.Amount = 6000 }, true},
+		{"claimed merkle root", buildTemplate(t, script, 5000, ours), func(b *pb.CandidateBlock) { b.Merkleroot = chainhash.Hash{2}.String() }, true},
+		{"claimed bits", buildTemplate(t, script, 5000, ours), func(b *pb.CandidateBlock) { b.Bits = "1d00ffff" }, true},
+		{"header of another block", buildTemplate(t, script, 5000, ours), func(b *pb.CandidateBlock) { b.Header = buildTemplate(t, script, 5000, theirs).Header }, true},
+		{"garbage", buildTemplate(t, script, 5000, ours), func(b *pb.CandidateBlock) { b.Block = b.Block[:40] }, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			block := proto.Clone(test.block).(*pb.CandidateBlock)
+			if test.tamper != nil {
+				test.tamper(block)
+			}
+			err := VerifyTemplate(block, request, params)
+			if test.fail != (err != nil) {
+				t.Fatalf("fail=%v, got=%v", test.fail, err)
+			}
+			if err != nil && !errors.Is(err, ErrUntrustedTemplate) {
+				t.Fatalf("unexpected error type: %v", err)
+			}
+		})
+	}
+
+	// without addresses or coinbase script, only consistency is checked
+	if err := VerifyTemplate(buildTemplate(t, nil, 5000, theirs), &pb.CandidateRequest{}, params); err != nil {
+		t.Fatal(fmt.Errorf("unexpected error without expectations: %w", err))
+	}
+}
+
+func TestVerifyTemplateWeights(t *testing.T) {
+	params := &chaincfg.TestNet3Params
+	first, _ := chainutil.NewAddressPubKeyHash(bytesOf(1), params)
+	second, _ := chainutil.NewAddressPubKeyHash(bytesOf(3), params)
+	payees := []chainutil.Address{first, second}
+
+	request := &pb.CandidateRequest{Payouts: []*pb.Payout{
+		{Address: first.EncodeAddress(), Weight: 1},
+		{Address: second.EncodeAddress(), Weight: 2},
+	}}
+
+	tests := []struct {
+		name   string
+		values []int64
+		fail   bool
+	}{
+		{"weighted", []int64{3334, 6666}, false},
+		{"remainder to the second", []int64{3333, 6667}, false},
+		{"even split", []int64{5000, 5000}, true},
+		{"all to the first", []int64{10000, 0}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := VerifyTemplate(buildSplitTemplate(t, nil, test.values, payees), request, params)
+			if test.fail != (err != nil) {
+				t.Fatalf("fail=%v, got=%v", test.fail, err)
+			}
+			if err != nil && !errors.Is(err, ErrUntrustedTemplate) {
+				t.Fatalf("unexpected error type: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package pool
+
+import (
+	"sync"
+
+	"github.com/flokiorg/grpc-miner/mining/pb"
+)
+
+// templateCache keeps the jobs handed out to miners so compact solutions can
+// reference them by id. A clean job voids every job cached before it, and the
+// oldest jobs are evicted once size is reached.
+type templateCache struct {
+	mu    sync.Mutex
+	size  int
+	jobs  map[string]*pb.CandidateBlock
+	order []string
+}
+
+func newTemplateCache(size int) *templateCache {
+	return &templateCache{
+		size: size,
+		jobs: make(map[string]*pb.CandidateBlock),
+	}
+}
+
+func (c *templateCache) Put(block *pb.CandidateBlock) {
+	if block.JobId == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.jobs[block.JobId]; ok {
+		return
+	}
+	if block.CleanJobs {
+		clear(c.jobs)
+		c.order = c.order[:0]
+	}
+	if len(c.order) == c.size {
+		delete(c.jobs, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.jobs[block.JobId] = block
+	c.order = append(c.order, block.JobId)
+}
+
+func (c *templateCache) Get(id string) *pb.CandidateBlock {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.jobs[id]
+}
@@ -0,0 +1,31 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package pool
+
+import (
+	"testing"
+
+	"github.com/flokiorg/grpc-miner/mining/pb"
+)
+
+func TestTemplateCache(t *testing.T) {
+	cache := newTemplateCache(2)
+
+	cache.Put(&pb.CandidateBlock{JobId: "1", CleanJobs: true})
+	cache.Put(&pb.CandidateBlock{JobId: "2"})
+	if cache.Get("1") == nil || cache.Get("2") == nil {
+		t.Fatal("expected both jobs to be cached")
+	}
+
+	cache.Put(&pb.CandidateBlock{JobId: "3"})
+	if cache.Get("1") != nil || cache.Get("3") == nil {
+		t.Fatal("expected the oldest job to be evicted")
+	}
+
+	cache.Put(&pb.CandidateBlock{JobId: "4", CleanJobs: true})
+	if cache.Get("2") != nil || cache.Get("3") != nil || cache.Get("4") == nil {
+		t.Fatal("expected a clean job to void previous jobs")
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package pool
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/flokiorg/go-flokicoin/blockchain"
+	"github.com/flokiorg/go-flokicoin/chaincfg"
+	"github.com/flokiorg/go-flokicoin/wire"
+	"github.com/flokiorg/grpc-miner/hash/scrypt"
+	"github.com/flokiorg/grpc-miner/mining/pb"
+	"github.com/flokiorg/grpc-miner/utils"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ChainBackend keeps a chain of its own on top of the genesis block of its
+// network, so the pool hands out real blocks without a node. Blocks only need
+// a valid proof of work and to extend the tip, it is meant for regtest chains
+// and integration tests.
+type ChainBackend struct {
+	// Bits is the target of every block, the network's proof of work limit
+	// unless set.
+	Bits uint32
+
+	// Time returns the timestamp of the block at height, TargetTimePerBlock
+	// after its parent unless set.
+	Time func(height int64) time.Time
+
+	// Generator serves the Generate call, when set.
+	Generator func(ctx context.Context, numBlocks int) ([]string, error)
+
+	mu     sync.Mutex
+	params *chaincfg.Params
+	blocks []*wire.MsgBlock
+	subs   map[*chainSub]struct{}
+	jobs   uint64
+}
+
+// chainSub is a miner following the tip.
+type chainSub struct {
+	out     chan *pb.CandidateBlock
+	request *pb.CandidateRequest
+	plan    *payoutPlan
+}
+
+func NewChainBackend(params *chaincfg.Params) *ChainBackend {
+	return &ChainBackend{
+		Bits:   params.PowLimitBits,
+		params: params,
+		blocks: []*wire.MsgBlock{params.GenesisBlock},
+		subs:   make(map[*chainSub]struct{}),
+	}
+}
+
+func (b *ChainBackend) Templates(ctx context.Context, request *pb.CandidateRequest) (<-chan *pb.CandidateBlock, error) {
+	plan, err := planPayouts(request, b.params)
+	if err != nil {
+		return nil, err
+	}
+	sub := &chainSub{out: make(chan *pb.CandidateBlock, 1), request: request, plan: plan}
+
+	b.mu.Lock()
+	block, err := b.template(sub)
+	if err != nil {
+		b.mu.Unlock()
+		return nil, err
+	}
+	sub.out <- block
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.subs, sub)
+		b.mu.Unlock()
+	}()
+
+	return sub.out, nil
+}
+
+// template builds the next block for sub, b.mu must be held.
+func (b *ChainBackend) template(sub *chainSub) (*pb.CandidateBlock, error) {
+	tip := b.blocks[len(b.blocks)-1]
+	height := int64(len(b.blocks))
+
+	timestamp := tip.Header.Timestamp.Add(b.params.TargetTimePerBlock)
+	if b.Time != nil {
+		timestamp = b.Time(height)
+	}
+
+	block, err := buildBlock(&blockTemplate{
+		Version:       0x20000000,
+		PreviousHash:  tip.BlockHash().String(),
+		CoinbaseValue: blockchain.CalcBlockSubsidy(int32(height), b.params),
+		Bits:          fmt.Sprintf("%08x", b.Bits),
+		Height:        height,
+		CurTime:       timestamp.Unix(),
+	}, sub.request, sub.plan)
+	if err != nil {
+		return nil, err
+	}
+
+	b.jobs++
+	block.JobId = fmt.Sprintf("%x", b.jobs)
+	block.CleanJobs = true
+	return block, nil
+}
+
+func (b *ChainBackend) Submit(ctx context.Context, block *pb.CandidateBlock, nonce uint32) (*pb.AckBlockSubmited, error) {
+	var msgBlock wire.MsgBlock
+	if err := msgBlock.Deserialize(bytes.NewReader(block.Block)); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "malformed block: %v", err)
+	}
+	msgBlock.Header.Nonce = nonce
+
+	var header bytes.Buffer
+	if err := msgBlock.Header.Serialize(&header); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if err := checkProofOfWork(header.Bytes(), msgBlock.Header.Bits); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	hash := msgBlock.BlockHash()
+	for _, known := range b.blocks {
+		if known.BlockHash() == hash {
+			return nil, status.Error(codes.AlreadyExists, "duplicate submission")
+		}
+	}
+	if tip := b.blocks[len(b.blocks)-1].BlockHash(); msgBlock.Header.PrevBlock != tip {
+		return nil, status.Errorf(codes.FailedPrecondition, "block does not extend the tip %s", tip)
+	}
+	b.blocks = append(b.blocks, &msgBlock)
+
+	for sub := range b.subs {
+		next, err := b.template(sub)
+		if err != nil {
+			log.Error().Err(err).Msgf("failed to build block %d", len(b.blocks))
+			continue
+		}
+		// drop a template the miner did not pick up yet, the new one replaces it
+		select {
+		case <-sub.out:
+		default:
+		}
+		sub.out <- next
+	}
+
+	return &pb.AckBlockSubmited{Header: hex.EncodeToString(header.Bytes())}, nil
+}
+
+// checkProofOfWork checks the scrypt hash of a serialized header against bits.
+func checkProofOfWork(header []byte, bits uint32) error {
+	hash, err := scrypt.Key(header, header, 1024, 1, 1, 32)
+	if err != nil {
+		return err
+	}
+	utils.ReverseBytes(hash)
+
+	if new(big.Int).SetBytes(hash).Cmp(blockchain.CompactToBig(bits)) > 0 {
+		return fmt.Errorf("proof of work %x above target %08x", hash, bits)
+	}
+	return nil
+}
+
+func (b *ChainBackend) Generate(ctx context.Context, numBlocks int) ([]string, error) {
+	if b.Generator == nil {
+		return nil, status.Error(codes.Unimplemented, "no generator attached to the chain")
+	}
+	return b.Generator(ctx, numBlocks)
+}
+
+// BlockStatus reports blocks of the chain as confirmed, the chain never
+// reorganizes so none is ever orphaned.
+func (b *ChainBackend) BlockStatus(ctx context.Context, hashes []string) ([]*pb.BlockInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	heights := make(map[string]int64, len(b.blocks))
+	for height, block := range b.blocks {
+		heights[block.BlockHash().String()] = int64(height)
+	}
+
+	tip := int64(len(b.blocks) - 1)
+	blocks := make([]*pb.BlockInfo, 0, len(hashes))
+	for _, hash := range hashes {
+		height, ok := heights[hash]
+		if !ok {
+			blocks = append(blocks, &pb.BlockInfo{Hash: hash, State: pb.BlockState_BLOCK_UNKNOWN})
+			continue
+		}
+		info := &pb.BlockInfo{Hash: hash, State: pb.BlockState_BLOCK_CONFIRMED, Height: height, Confirmations: tip - height + 1}
+		if info.Confirmations > int64(b.params.CoinbaseMaturity) {
+			info.State = pb.BlockState_BLOCK_MATURED
+		}
+		blocks = append(blocks, info)
+	}
+	return blocks, nil
+}
+
+// Blocks returns the chain from the genesis block to the tip.
+func (b *ChainBackend) Blocks() []*wire.MsgBlock {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]*wire.MsgBlock(nil), b.blocks...)
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package pool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/flokiorg/go-flokicoin/chaincfg"
+	"github.com/flokiorg/grpc-miner/mining/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestChainBackendSubmit(t *testing.T) {
+	backend := NewChainBackend(&chaincfg.RegressionNetParams)
+	backend.Bits = 0x1d00ffff
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	request := &pb.CandidateRequest{MiningAddrs: []string{testAddress(t).EncodeAddress()}, CoinbaseScript: &pb.CoinbaseScript{}}
+	templates, err := backend.Templates(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := <-templates
+	if block.Height != 1 || block.Bits != "1d00ffff" {
+		t.Fatalf("unexpected template %v", block)
+	}
+
+	// no nonce meets this target on the first try
+	if _, err := backend.Submit(ctx, block, 0); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition, got %v", err)
+	}
+	if len(backend.Blocks()) != 1 {
+		t.Fatal("block without proof of work was added to the chain")
+	}
+
+	infos, err := backend.BlockStatus(ctx, []string{chaincfg.RegressionNetParams.GenesisHash.String()})
+	if err != nil || infos[0].State != pb.BlockState_BLOCK_CONFIRMED || infos[0].Confirmations != 1 {
+		t.Fatalf("unexpected genesis status %v: %v", infos, err)
+	}
+}
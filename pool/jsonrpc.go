@@ -0,0 +1,108 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package pool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// RPCError is an error returned by the node for a JSON-RPC call.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// rpcClient speaks flokicoind's JSON-RPC over HTTP.
+type rpcClient struct {
+	url      string
+	user     string
+	password string
+	http     *http.Client
+	id       atomic.Uint64
+}
+
+func newRPCClient(host, user, password string) *rpcClient {
+	url := host
+	if !strings.Contains(url, "://") {
+		url = "http://" + url
+	}
+	return &rpcClient{url: url, user: user, password: password, http: &http.Client{}}
+}
+
+// call invokes method and decodes its result into result, unless it is nil.
+func (c *rpcClient) call(ctx context.Context, method string, params []any, result any) error {
+	if params == nil {
+		params = []any{}
+	}
+	body, err := json.Marshal(map[string]any{
+		"jsonrpc": "1.0",
+		"id":      c.id.Add(1),
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.user != "" || c.password != "" {
+		req.SetBasicAuth(c.user, c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+
+	// nodes answer RPC errors with a 500 and a JSON body, anything else is a transport failure
+	var reply struct {
+		Result json.RawMessage `json:"result"`
+		Error  *RPCError       `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &reply); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("%s: node answered %s", method, resp.Status)
+		}
+		return fmt.Errorf("%s: malformed reply: %w", method, err)
+	}
+	if reply.Error != nil {
+		return fmt.Errorf("%s: %w", method, reply.Error)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(reply.Result, result); err != nil {
+		return fmt.Errorf("%s: malformed result: %w", method, err)
+	}
+	return nil
+}
+
+// isRPCError reports whether err was returned by the node rather than by the transport.
+func isRPCError(err error) bool {
+	var rpcErr *RPCError
+	return errors.As(err, &rpcErr)
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package pool
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+	"sync"
+
+	. "github.com/flokiorg/grpc-miner/mining/algo/common"
+	"github.com/flokiorg/grpc-miner/mining/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Submission is a solved block received by the MemoryBackend.
+type Submission struct {
+	Template *pb.CandidateBlock
+	Nonce    uint32
+	Header   string
+}
+
+// MemoryBackend broadcasts templates pushed by the caller and records
+// submissions; it is meant for tests and local experiments.
+type MemoryBackend struct {
+	mu        sync.Mutex
+	current   *pb.CandidateBlock
+	subs      map[chan *pb.CandidateBlock]struct{}
+	submitted []Submission
+	states    map[string]*pb.BlockInfo
+	jobs      uint64
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		subs:   make(map[chan *pb.CandidateBlock]struct{}),
+		states: make(map[string]*pb.BlockInfo),
+	}
+}
+
+// Push makes block the current template and sends it to every open stream.
+// Templates without a job id get one, flagged clean unless they build on the
+// same parent as the previous template.
+func (b *MemoryBackend) Push(block *pb.CandidateBlock) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if block.JobId == "" {
+		block = proto.Clone(block).(*pb.CandidateBlock)
+		b.jobs++
+		block.JobId = strconv.FormatUint(b.jobs, 16)
+		block.CleanJobs = b.current == nil || b.current.Height != block.Height || parent(b.current) != parent(block)
+	}
+
+	b.current = block
+	for sub := range b.subs {
+		// drop a template the miner did not pick up yet, the new one replaces it
+		select {
+		case <-sub:
+		default:
+		}
+		sub <- block
+	}
+}
+
+func parent(block *pb.CandidateBlock) string {
+	// version (4 bytes) followed by the previous block hash (32 bytes)
+	if len(block.Header) < 72 {
+		return ""
+	}
+	return block.Header[8:72]
+}
+
+func (b *MemoryBackend) Templates(ctx context.Context, request *pb.CandidateRequest) (<-chan *pb.CandidateBlock, error) {
+	sub := make(chan *pb.CandidateBlock, 1)
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	if b.current != nil {
+		sub <- b.current
+	}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.subs, sub)
+		b.mu.Unlock()
+	}()
+
+	return sub, nil
+}
+
+func (b *MemoryBackend) Submit(ctx context.Context, block *pb.CandidateBlock, nonce uint32) (*pb.AckBlockSubmited, error) {
+	if len(block.Header) < BLOCK_NONCELESS_LENGTH {
+		return nil, status.Errorf(codes.InvalidArgument, "template header too short: %d", len(block.Header))
+	}
+
+	nonceBytes := binary.LittleEndian.AppendUint32(nil, nonce)
+	header := block.Header[:BLOCK_NONCELESS_LENGTH] + hex.EncodeToString(nonceBytes)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, s := range b.submitted {
+		if s.Header == header {
+			return nil, status.Error(codes.AlreadyExists, "duplicate submission")
+		}
+	}
+	b.submitted = append(b.submitted, Submission{Template: block, Nonce: nonce, Header: header})
+
+	return &pb.AckBlockSubmited{Header: header}, nil
+}
+
+func (b *MemoryBackend) Generate(ctx context.Context, numBlocks int) ([]string, error) {
+	return nil, status.Error(codes.Unimplemented, "generate is not supported by the memory backend")
+}
+
+// Submitted returns the solutions received so far.
+func (b *MemoryBackend) Submitted() []Submission {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Submission(nil), b.submitted...)
+}
+
+// SetBlockStatus sets what BlockStatus reports for info.Hash, blocks are
+// unknown until then.
+func (b *MemoryBackend) SetBlockStatus(info *pb.BlockInfo) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.states[info.Hash] = proto.Clone(info).(*pb.BlockInfo)
+}
+
+func (b *MemoryBackend) BlockStatus(ctx context.Context, hashes []string) ([]*pb.BlockInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	blocks := make([]*pb.BlockInfo, 0, len(hashes))
+	for _, hash := range hashes {
+		if info, ok := b.states[hash]; ok {
+			blocks = append(blocks, proto.Clone(info).(*pb.BlockInfo))
+			continue
+		}
+		blocks = append(blocks, &pb.BlockInfo{Hash: hash, State: pb.BlockState_BLOCK_UNKNOWN})
+	}
+	return blocks, nil
+}
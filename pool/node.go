@@ -0,0 +1,419 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package pool
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/flokiorg/go-flokicoin/blockchain"
+	"github.com/flokiorg/go-flokicoin/chaincfg"
+	"github.com/flokiorg/go-flokicoin/chaincfg/chainhash"
+	"github.com/flokiorg/go-flokicoin/chainutil"
+	"github.com/flokiorg/go-flokicoin/txscript"
+	"github.com/flokiorg/go-flokicoin/wire"
+	"github.com/flokiorg/grpc-miner/common"
+	"github.com/flokiorg/grpc-miner/mining/pb"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// rpcBlockNotFound is the JSON-RPC error code of an unknown block.
+const rpcBlockNotFound = -5
+
+const (
+	defaultPollInterval    = 2 * time.Second
+	defaultRefreshInterval = 30 * time.Second
+)
+
+// NodeBackend mines solo: templates come from a flokicoind node through
+// getblocktemplate, the coinbase is built locally and solutions go straight
+// back to the node with submitblock.
+type NodeBackend struct {
+	// PollInterval is how often the node is asked for a new template, and
+	// RefreshInterval how old a template may get before it is rebuilt with
+	// the transactions that arrived meanwhile.
+	PollInterval    time.Duration
+	RefreshInterval time.Duration
+
+	rpc    *rpcClient
+	params *chaincfg.Params
+	jobs   atomic.Uint64
+}
+
+func NewNodeBackend(host, user, password string, params *chaincfg.Params) *NodeBackend {
+	return &NodeBackend{
+		PollInterval:    defaultPollInterval,
+		RefreshInterval: defaultRefreshInterval,
+		rpc:             newRPCClient(host, user, password),
+		params:          params,
+	}
+}
+
+// blockTemplate is the part of the getblocktemplate result needed to build a block.
+type blockTemplate struct {
+	Version           int32  `json:"version"`
+	PreviousHash      string `json:"previousblockhash"`
+	CoinbaseValue     int64  `json:"coinbasevalue"`
+	Bits              string `json:"bits"`
+	Height            int64  `json:"height"`
+	CurTime           int64  `json:"curtime"`
+	WitnessCommitment string `json:"default_witness_commitment"`
+	Transactions      []struct {
+		Data string `json:"data"`
+	} `json:"transactions"`
+}
+
+func (b *NodeBackend) Templates(ctx context.Context, request *pb.CandidateRequest) (<-chan *pb.CandidateBlock, error) {
+	plan, err := planPayouts(request, b.params)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := b.template(ctx)
+	if err != nil {
+		return nil, err
+	}
+	block, err := buildBlock(tmpl, request, plan)
+	if err != nil {
+		return nil, err
+	}
+	b.assignJob(block, true)
+
+	out := make(chan *pb.CandidateBlock, 1)
+	out <- block
+	go b.poll(ctx, tmpl, request, plan, out)
+	return out, nil
+}
+
+// poll sends a new block whenever the tip moves or the current one gets old.
+func (b *NodeBackend) poll(ctx context.Context, last *blockTemplate, request *pb.CandidateRequest, plan *payoutPlan, out chan *pb.CandidateBlock) {
+	defer close(out)
+
+	ticker := time.NewTicker(b.PollInterval)
+	defer ticker.Stop()
+	built := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		tmpl, err := b.template(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Warn().Err(err).Msg("failed to fetch block template from node")
+			}
+			continue
+		}
+		if tmpl.PreviousHash == last.PreviousHash && time.Since(built) < b.RefreshInterval {
+			continue
+		}
+
+		block, err := buildBlock(tmpl, request, plan)
+		if err != nil {
+			log.Error().Err(err).Msgf("failed to build block %d from node template", tmpl.Height)
+			continue
+		}
+		b.assignJob(block, tmpl.PreviousHash != last.PreviousHash)
+		last, built = tmpl, time.Now()
+
+		// drop a block the miner did not pick up yet, the new one replaces it
+		select {
+		case <-out:
+		default:
+		}
+		out <- block
+	}
+}
+
+// assignJob lets the miner keep working on a refreshed block until the tip moves.
+func (b *NodeBackend) assignJob(block *pb.CandidateBlock, clean bool) {
+	block.JobId = strconv.FormatUint(b.jobs.Add(1), 16)
+	block.CleanJobs = clean
+}
+
+func (b *NodeBackend) template(ctx context.Context) (*blockTemplate, error) {
+	var tmpl blockTemplate
+	params := map[string]any{"rules": []string{"segwit"}}
+	if err := b.rpc.call(ctx, "getblocktemplate", []any{params}, &tmpl); err != nil {
+		return nil, rpcStatus(err)
+	}
+	return &tmpl, nil
+}
+
+// payout is a coinbase output, paid its weight's share of the reward.
+type payout struct {
+	address chainutil.Address
+	weight  int64
+}
+
+// payoutPlan says who the coinbase pays: all payouts at once when the reward
+// is split, otherwise one of them in turn block after block.
+type payoutPlan struct {
+	payouts []payout
+	split   bool
+}
+
+func (p *payoutPlan) outputs(height int64) []payout {
+	if p.split {
+		return p.payouts
+	}
+	return []payout{p.payouts[height%int64(len(p.payouts))]}
+}
+
+// planPayouts decodes the addresses the coinbase may pay.
+func planPayouts(request *pb.CandidateRequest, params *chaincfg.Params) (*payoutPlan, error) {
+	if len(request.MiningAddrs) == 0 && len(request.Payouts) == 0 {
+		if request.Xpub != "" {
+			return nil, status.Error(codes.Unimplemented, "xpub payouts are not supported when mining solo, set mining addresses")
+		}
+		return nil, status.Error(codes.InvalidArgument, "solo mining requires at least one mining address")
+	}
+
+	plan := &payoutPlan{split: len(request.Payouts) > 0}
+	entries := request.Payouts
+	if !plan.split {
+		for _, encoded := range request.MiningAddrs {
+			entries = append(entries, &pb.Payout{Address: encoded, Weight: 1})
+		}
+	}
+
+	for _, entry := range entries {
+		addr, err := chainutil.DecodeAddress(entry.Address, params)
+		if err != nil || !addr.IsForNet(params) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid mining address %s for %s", entry.Address, params.Name)
+		}
+		if entry.Weight == 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "mining address %s has no weight", entry.Address)
+		}
+		plan.payouts = append(plan.payouts, payout{address: addr, weight: int64(entry.Weight)})
+	}
+	return plan, nil
+}
+
+// buildBlock assembles the block described by tmpl with a coinbase following plan.
+func buildBlock(tmpl *blockTemplate, request *pb.CandidateRequest, plan *payoutPlan) (*pb.CandidateBlock, error) {
+	outputs := plan.outputs(tmpl.Height)
+
+	coinbase, err := coinbaseTx(tmpl, request, outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	txs := []*chainutil.Tx{chainutil.NewTx(coinbase)}
+	for i, entry := range tmpl.Transactions {
+		raw, err := hex.DecodeString(entry.Data)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		var tx wire.MsgTx
+		if err := tx.Deserialize(bytes.NewReader(raw)); err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		txs = append(txs, chainutil.NewTx(&tx))
+	}
+
+	prevHash, err := chainhash.NewHashFromStr(tmpl.PreviousHash)
+	if err != nil {
+		return nil, fmt.Errorf("previous block hash: %w", err)
+	}
+	bits, err := strconv.ParseUint(tmpl.Bits, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("bits: %w", err)
+	}
+
+	merkleRoot := blockchain.CalcMerkleRoot(txs, false)
+	msgBlock := wire.NewMsgBlock(wire.NewBlockHeader(tmpl.Version, prevHash, &merkleRoot, uint32(bits), 0))
+	msgBlock.Header.Timestamp = time.Unix(tmpl.CurTime, 0)
+	for _, tx := range txs {
+		msgBlock.AddTransaction(tx.MsgTx())
+	}
+
+	var header, block bytes.Buffer
+	if err := msgBlock.Header.Serialize(&header); err != nil {
+		return nil, err
+	}
+	if err := msgBlock.Serialize(&block); err != nil {
+		return nil, err
+	}
+
+	return &pb.CandidateBlock{
+		Bits:         tmpl.Bits,
+		Header:       hex.EncodeToString(header.Bytes()),
+		Height:       tmpl.Height,
+		Merkleroot:   merkleRoot.String(),
+		Amount:       tmpl.CoinbaseValue,
+		Transactions: int64(len(txs)),
+		Block:        block.Bytes(),
+		Address:      outputs[0].address.EncodeAddress(),
+		Version:      int64(tmpl.Version),
+	}, nil
+}
+
+// coinbaseTx splits the reward between outputs by weight, the first one
+// getting the rounding remainder. Its script holds the height, then the
+// coinbase script text, evaluated for the template, between random bytes so
+// rigs sharing a node never search the same blocks.
+func coinbaseTx(tmpl *blockTemplate, request *pb.CandidateRequest, outputs []payout) (*wire.MsgTx, error) {
+	left, right, text := int64(common.DefaultCBSBoundaryBytesSize), int64(0), []byte(nil)
+	if cbs := request.CoinbaseScript; cbs != nil {
+		expanded, err := common.ExpandCoinbaseText(cbs.Text, common.CoinbaseVars{
+			Worker: request.GetWorker().GetName(),
+			Rig:    request.GetWorker().GetRigId(),
+			Height: tmpl.Height,
+			Time:   time.Unix(tmpl.CurTime, 0),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if cbs.BytesLeft < 0 || cbs.BytesRight < 0 || cbs.BytesLeft+int64(len(expanded))+cbs.BytesRight > common.MaxCoinbaseScriptSize {
+			return nil, fmt.Errorf("coinbase script [%d:%q:%d] exceeds %d bytes", cbs.BytesLeft, expanded, cbs.BytesRight, common.MaxCoinbaseScriptSize)
+		}
+		left, right, text = cbs.BytesLeft, cbs.BytesRight, expanded
+	}
+
+	extranonce := make([]byte, left+right)
+	if _, err := rand.Read(extranonce); err != nil {
+		return nil, err
+	}
+	script, err := txscript.NewScriptBuilder().AddInt64(tmpl.Height).Script()
+	if err != nil {
+		return nil, err
+	}
+	script = append(script, extranonce[:left]...)
+	script = append(script, text...)
+	script = append(script, extranonce[left:]...)
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: *wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex),
+		SignatureScript:  script,
+		Sequence:         wire.MaxTxInSequenceNum,
+	})
+
+	var total int64
+	for _, output := range outputs {
+		total += output.weight
+	}
+	remainder := tmpl.CoinbaseValue
+	for _, output := range outputs {
+		pkScript, err := txscript.PayToAddrScript(output.address)
+		if err != nil {
+			return nil, err
+		}
+		value := share(tmpl.CoinbaseValue, output.weight, total)
+		remainder -= value
+		tx.AddTxOut(wire.NewTxOut(value, pkScript))
+	}
+	tx.TxOut[0].Value += remainder
+
+	if tmpl.WitnessCommitment != "" {
+		commitment, err := hex.DecodeString(tmpl.WitnessCommitment)
+		if err != nil {
+			return nil, fmt.Errorf("witness commitment: %w", err)
+		}
+		tx.AddTxOut(wire.NewTxOut(0, commitment))
+		tx.TxIn[0].Witness = wire.TxWitness{make([]byte, blockchain.CoinbaseWitnessDataLen)}
+	}
+	return tx, nil
+}
+
+// share returns value*weight/total rounded down, without overflowing.
+func share(value, weight, total int64) int64 {
+	hi, lo := bits.Mul64(uint64(value), uint64(weight))
+	quo, _ := bits.Div64(hi, lo, uint64(total))
+	return int64(quo)
+}
+
+func (b *NodeBackend) Submit(ctx context.Context, block *pb.CandidateBlock, nonce uint32) (*pb.AckBlockSubmited, error) {
+	var msgBlock wire.MsgBlock
+	if err := msgBlock.Deserialize(bytes.NewReader(block.Block)); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "malformed block: %v", err)
+	}
+	msgBlock.Header.Nonce = nonce
+
+	var header, raw bytes.Buffer
+	if err := msgBlock.Header.Serialize(&header); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if err := msgBlock.Serialize(&raw); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	// submitblock answers null once the block is accepted, and the reason otherwise
+	var reason *string
+	if err := b.rpc.call(ctx, "submitblock", []any{hex.EncodeToString(raw.Bytes())}, &reason); err != nil {
+		return nil, rpcStatus(err)
+	}
+	if reason != nil && *reason != "inconclusive" {
+		if *reason == "duplicate" {
+			return nil, status.Error(codes.AlreadyExists, "duplicate submission")
+		}
+		return nil, status.Errorf(codes.FailedPrecondition, "block rejected: %s", *reason)
+	}
+
+	return &pb.AckBlockSubmited{Header: hex.EncodeToString(header.Bytes())}, nil
+}
+
+func (b *NodeBackend) Generate(ctx context.Context, numBlocks int) ([]string, error) {
+	var hashes []string
+	if err := b.rpc.call(ctx, "generate", []any{numBlocks}, &hashes); err != nil {
+		return nil, rpcStatus(err)
+	}
+	return hashes, nil
+}
+
+// BlockStatus looks the blocks up with getblockheader. Nodes report blocks
+// that a reorganization left out of the main chain with no confirmations.
+func (b *NodeBackend) BlockStatus(ctx context.Context, hashes []string) ([]*pb.BlockInfo, error) {
+	blocks := make([]*pb.BlockInfo, 0, len(hashes))
+	for _, hash := range hashes {
+		var header struct {
+			Height        int64 `json:"height"`
+			Confirmations int64 `json:"confirmations"`
+		}
+		info := &pb.BlockInfo{Hash: hash}
+
+		err := b.rpc.call(ctx, "getblockheader", []any{hash, true}, &header)
+		var rpcErr *RPCError
+		switch {
+		case errors.As(err, &rpcErr) && rpcErr.Code == rpcBlockNotFound:
+			info.State = pb.BlockState_BLOCK_UNKNOWN
+		case err != nil:
+			return nil, rpcStatus(err)
+		case header.Confirmations < 1:
+			info.State, info.Height = pb.BlockState_BLOCK_ORPHANED, header.Height
+		default:
+			info.State, info.Height, info.Confirmations = pb.BlockState_BLOCK_CONFIRMED, header.Height, header.Confirmations
+			// the coinbase is spendable once the tip is CoinbaseMaturity blocks past it
+			if header.Confirmations > int64(b.params.CoinbaseMaturity) {
+				info.State = pb.BlockState_BLOCK_MATURED
+			}
+		}
+		blocks = append(blocks, info)
+	}
+	return blocks, nil
+}
+
+// rpcStatus tells the miner whether to retry: the node refusing a call is
+// final, failing to reach it is not.
+func rpcStatus(err error) error {
+	if isRPCError(err) {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Error(codes.Unavailable, err.Error())
+}
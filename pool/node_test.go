@@ -0,0 +1,340 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package pool
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flokiorg/go-flokicoin/blockchain"
+	"github.com/flokiorg/go-flokicoin/chaincfg"
+	"github.com/flokiorg/go-flokicoin/chaincfg/chainhash"
+	"github.com/flokiorg/go-flokicoin/chainutil"
+	"github.com/flokiorg/go-flokicoin/txscript"
+	"github.com/flokiorg/go-flokicoin/wire"
+	"github.com/flokiorg/grpc-miner/mining/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const witnessCommitment = "6a24aa21a9ed0000000000000000000000000000000000000000000000000000000000000000"
+
+// stubNode answers the JSON-RPC calls NodeBackend makes.
+type stubNode struct {
+	mu        sync.Mutex
+	height    int64
+	prevHash  string
+	tx        string
+	reason    any
+	submitted []string
+	headers   map[string]map[string]int64
+}
+
+func (n *stubNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     uint64            `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var result any
+	switch req.Method {
+	case "getblocktemplate":
+		result = map[string]any{
+			"version":                    0x20000000,
+			"previousblockhash":          n.prevHash,
+			"coinbasevalue":              int64(5000000000),
+			"bits":                       "207fffff",
+			"height":                     n.height,
+			"curtime":                    int64(1700000000),
+			"default_witness_commitment": witnessCommitment,
+			"transactions":               []map[string]string{{"data": n.tx}},
+		}
+	case "submitblock":
+		var block string
+		json.Unmarshal(req.Params[0], &block)
+		n.submitted = append(n.submitted, block)
+		result = n.reason
+	case "getblockheader":
+		var hash string
+		json.Unmarshal(req.Params[0], &hash)
+		header, ok := n.headers[hash]
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]any{"id": req.ID, "result": nil, "error": RPCError{Code: rpcBlockNotFound, Message: "Block not found"}})
+			return
+		}
+		result = header
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{"id": req.ID, "result": nil, "error": RPCError{Code: -32601, Message: "Method not found"}})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"id": req.ID, "result": result, "error": nil})
+}
+
+func (n *stubNode) advance() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.height++
+	n.prevHash = strings.Repeat("0", 62) + hex.EncodeToString([]byte{byte(n.height)})
+}
+
+func newStubNode(t *testing.T) (*stubNode, string) {
+	t.Helper()
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), []byte{0x51}, nil))
+	tx.AddTxOut(wire.NewTxOut(1000, []byte{0x51}))
+	var raw bytes.Buffer
+	if err := tx.Serialize(&raw); err != nil {
+		t.Fatal(err)
+	}
+
+	node := &stubNode{height: 100, tx: hex.EncodeToString(raw.Bytes())}
+	node.advance()
+	srv := httptest.NewServer(node)
+	t.Cleanup(srv.Close)
+	return node, srv.URL
+}
+
+func testAddress(t *testing.T) chainutil.Address {
+	t.Helper()
+	addr, err := chainutil.NewAddressPubKeyHash(make([]byte, 20), &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addr
+}
+
+func TestNodeBackendTemplates(t *testing.T) {
+	node, url := newStubNode(t)
+	backend := NewNodeBackend(url, "user", "pass", &chaincfg.RegressionNetParams)
+	backend.PollInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	addr := testAddress(t)
+	request := &pb.CandidateRequest{
+		MiningAddrs:    []string{addr.EncodeAddress()},
+		CoinbaseScript: &pb.CoinbaseScript{BytesLeft: 4, BytesRight: 2, Text: "/{worker}/"},
+		Worker:         &pb.Worker{Name: "gminer"},
+	}
+	templates, err := backend.Templates(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := <-templates
+	if block.Height != 101 || block.Address != addr.EncodeAddress() || block.Transactions != 2 {
+		t.Fatalf("unexpected template: height=%d address=%s transactions=%d", block.Height, block.Address, block.Transactions)
+	}
+
+	var msgBlock wire.MsgBlock
+	if err := msgBlock.Deserialize(bytes.NewReader(block.Block)); err != nil {
+		t.Fatal(err)
+	}
+	var header bytes.Buffer
+	msgBlock.Header.Serialize(&header)
+	if got := hex.EncodeToString(header.Bytes()); got != block.Header {
+		t.Fatalf("header does not match block, want=%s got=%s", block.Header, got)
+	}
+
+	txs := make([]*chainutil.Tx, 0, len(msgBlock.Transactions))
+	for _, tx := range msgBlock.Transactions {
+		txs = append(txs, chainutil.NewTx(tx))
+	}
+	if root := blockchain.CalcMerkleRoot(txs, false); root != msgBlock.Header.MerkleRoot || root.String() != block.Merkleroot {
+		t.Fatalf("unexpected merkle root %s", msgBlock.Header.MerkleRoot)
+	}
+
+	coinbase := msgBlock.Transactions[0]
+	pkScript, _ := txscript.PayToAddrScript(addr)
+	if !bytes.Equal(coinbase.TxOut[0].PkScript, pkScript) || coinbase.TxOut[0].Value != 5000000000 {
+		t.Fatal("coinbase does not pay the mining address")
+	}
+	if hex.EncodeToString(coinbase.TxOut[1].PkScript) != witnessCommitment || len(coinbase.TxIn[0].Witness) != 1 {
+		t.Fatal("coinbase misses the witness commitment")
+	}
+	if script := coinbase.TxIn[0].SignatureScript; !bytes.Contains(script, []byte("/gminer/")) || len(script) != 2+4+8+2 {
+		t.Fatalf("unexpected coinbase script %x", script)
+	}
+
+	node.advance()
+	select {
+	case next := <-templates:
+		if next.Height != 102 || !next.CleanJobs || next.JobId == block.JobId {
+			t.Fatalf("expected a template for the new tip, got height %d", next.Height)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no template after the tip moved")
+	}
+}
+
+func TestNodeBackendSubmit(t *testing.T) {
+	node, url := newStubNode(t)
+	backend := NewNodeBackend(url, "", "", &chaincfg.RegressionNetParams)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	templates, err := backend.Templates(ctx, &pb.CandidateRequest{MiningAddrs: []string{testAddress(t).EncodeAddress()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := <-templates
+
+	ack, err := backend.Submit(ctx, block, 0x2a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := block.Header[:152] + "2a000000"; ack.Header != want {
+		t.Fatalf("unexpected ack header, want=%s got=%s", want, ack.Header)
+	}
+	node.mu.Lock()
+	submitted := node.submitted
+	node.mu.Unlock()
+	if len(submitted) != 1 || !strings.HasPrefix(submitted[0], ack.Header) {
+		t.Fatal("node did not receive the solved block")
+	}
+
+	tests := []struct {
+		reason string
+		code   codes.Code
+	}{
+		{"duplicate", codes.AlreadyExists},
+		{"high-hash", codes.FailedPrecondition},
+	}
+	for _, test := range tests {
+		node.mu.Lock()
+		node.reason = test.reason
+		node.mu.Unlock()
+
+		if _, err := backend.Submit(ctx, block, 0x2a); status.Code(err) != test.code {
+			t.Fatalf("%s: want=%s got=%v", test.reason, test.code, err)
+		}
+	}
+}
+
+func TestNodeBackendErrors(t *testing.T) {
+	_, url := newStubNode(t)
+	backend := NewNodeBackend(url, "", "", &chaincfg.RegressionNetParams)
+	mainnet, _ := chainutil.NewAddressPubKeyHash(make([]byte, 20), &chaincfg.MainNetParams)
+
+	tests := []struct {
+		name    string
+		backend *NodeBackend
+		request *pb.CandidateRequest
+		code    codes.Code
+	}{
+		{"no address", backend, &pb.CandidateRequest{}, codes.InvalidArgument},
+		{"xpub", backend, &pb.CandidateRequest{Xpub: "xpub"}, codes.Unimplemented},
+		{"malformed address", backend, &pb.CandidateRequest{MiningAddrs: []string{"nope"}}, codes.InvalidArgument},
+		{"wrong network", backend, &pb.CandidateRequest{MiningAddrs: []string{mainnet.EncodeAddress()}}, codes.InvalidArgument},
+		{"node down", NewNodeBackend("127.0.0.1:1", "", "", &chaincfg.RegressionNetParams), &pb.CandidateRequest{MiningAddrs: []string{testAddress(t).EncodeAddress()}}, codes.Unavailable},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.backend.Templates(context.Background(), test.request); status.Code(err) != test.code {
+				t.Fatalf("want=%s got=%v", test.code, err)
+			}
+		})
+	}
+
+	if _, err := backend.Generate(context.Background(), 1); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected the node error to be final, got=%v", err)
+	}
+}
+
+func TestNodeBackendSplit(t *testing.T) {
+	_, url := newStubNode(t)
+	backend := NewNodeBackend(url, "", "", &chaincfg.RegressionNetParams)
+
+	first := testAddress(t)
+	second, _ := chainutil.NewAddressPubKeyHash(bytes.Repeat([]byte{1}, 20), &chaincfg.RegressionNetParams)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	templates, err := backend.Templates(ctx, &pb.CandidateRequest{
+		MiningAddrs: []string{first.EncodeAddress(), second.EncodeAddress()},
+		Payouts: []*pb.Payout{
+			{Address: first.EncodeAddress(), Weight: 1},
+			{Address: second.EncodeAddress(), Weight: 2},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var msgBlock wire.MsgBlock
+	if err := msgBlock.Deserialize(bytes.NewReader((<-templates).Block)); err != nil {
+		t.Fatal(err)
+	}
+	outputs := msgBlock.Transactions[0].TxOut
+	// 5000000000 does not divide by 3, the remainder goes to the first output
+	if outputs[0].Value != 1666666667 || outputs[1].Value != 3333333333 {
+		t.Fatalf("unexpected split %d/%d", outputs[0].Value, outputs[1].Value)
+	}
+	pkScript, _ := txscript.PayToAddrScript(second)
+	if !bytes.Equal(outputs[1].PkScript, pkScript) {
+		t.Fatal("second output does not pay the second address")
+	}
+}
+
+func TestNodeBackendBlockStatus(t *testing.T) {
+	node, url := newStubNode(t)
+	backend := NewNodeBackend(url, "user", "pass", &chaincfg.RegressionNetParams)
+
+	hash := func(b byte) string { return strings.Repeat(hex.EncodeToString([]byte{b}), 32) }
+	node.headers = map[string]map[string]int64{
+		hash(1): {"height": 90, "confirmations": 12},
+		hash(2): {"height": 1, "confirmations": 101},
+		hash(3): {"height": 95, "confirmations": -1},
+	}
+
+	blocks, err := backend.BlockStatus(context.Background(), []string{hash(1), hash(2), hash(3), hash(4)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*pb.BlockInfo{
+		{Hash: hash(1), State: pb.BlockState_BLOCK_CONFIRMED, Height: 90, Confirmations: 12},
+		{Hash: hash(2), State: pb.BlockState_BLOCK_MATURED, Height: 1, Confirmations: 101},
+		{Hash: hash(3), State: pb.BlockState_BLOCK_ORPHANED, Height: 95},
+		{Hash: hash(4), State: pb.BlockState_BLOCK_UNKNOWN},
+	}
+	if len(blocks) != len(want) {
+		t.Fatalf("expected %d blocks, got %d", len(want), len(blocks))
+	}
+	for i := range want {
+		if !proto.Equal(blocks[i], want[i]) {
+			t.Fatalf("block %d: got %v, want %v", i, blocks[i], want[i])
+		}
+	}
+
+	// the server only asks the backend about well-formed hashes
+	_, err = NewServer(backend).BlockStatus(context.Background(), &pb.BlockStatusRequest{Hashes: []string{"00"}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
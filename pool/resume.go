@@ -0,0 +1,100 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package pool
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// sessionState is what a Session leaves behind for ResumeWindow, so a miner
+// reconnecting with its token can still submit solutions to the jobs it was
+// handed.
+type sessionState struct {
+	token   string
+	jobs    *templateCache
+	owner   *peer
+	expires time.Time
+}
+
+type resumeBook struct {
+	mu     sync.Mutex
+	states map[string]*sessionState
+}
+
+func newResumeBook() *resumeBook {
+	return &resumeBook{states: make(map[string]*sessionState)}
+}
+
+// attach hands the state of the session identified by token over to p. A new
+// session is started when the token is unknown or expired. The previous owner
+// may still be attached when its connection went half-open, it is dropped.
+func (b *resumeBook) attach(token string, p *peer, now time.Time) (*sessionState, bool) {
+	return b.attachAs(token, newToken(), p, now)
+}
+
+// attachConn hands the state kept for the connection conn over to p. Open
+// streams carry no token, the solutions to their jobs are matched to them by
+// the connection they arrive on.
+func (b *resumeBook) attachConn(conn string, p *peer, now time.Time) *sessionState {
+	state, _ := b.attachAs(connKey(conn), connKey(conn), p, now)
+	return state
+}
+
+// lookupConn returns the state kept for the connection conn, nil if none.
+func (b *resumeBook) lookupConn(conn string) *sessionState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.states[connKey(conn)]
+}
+
+func connKey(conn string) string {
+	return "conn/" + conn
+}
+
+// attachAs is attach, naming a new session fresh.
+func (b *resumeBook) attachAs(token, fresh string, p *peer, now time.Time) (*sessionState, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for t, state := range b.states {
+		if state.owner == nil && now.After(state.expires) {
+			delete(b.states, t)
+		}
+	}
+
+	if state, ok := b.states[token]; ok {
+		state.owner = p
+		return state, true
+	}
+
+	state := &sessionState{
+		token: fresh,
+		jobs:  newTemplateCache(maxJobs),
+		owner: p,
+	}
+	b.states[state.token] = state
+	return state, false
+}
+
+// detach keeps state resumable for window once p, its owner, disconnects.
+func (b *resumeBook) detach(state *sessionState, p *peer, window time.Duration, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if state.owner != p {
+		return
+	}
+	state.owner = nil
+	state.expires = now.Add(window)
+}
+
+func newToken() string {
+	token := make([]byte, 16)
+	rand.Read(token)
+	return hex.EncodeToString(token)
+}
@@ -0,0 +1,487 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+// Package pool is a reference implementation of the pool side of the
+// CandidateStream protocol. It hands out templates produced by a Backend and
+// relays solved blocks back to it.
+package pool
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/flokiorg/grpc-miner/mining/compress"
+	"github.com/flokiorg/grpc-miner/mining/pb"
+	"github.com/flokiorg/grpc-miner/utils"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcpeer "google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	ProtocolVersion    uint32 = 2
+	MinProtocolVersion uint32 = 1
+
+	// maxJobs bounds how many jobs stay valid for submission between clean jobs
+	maxJobs = 32
+
+	// maxStatusHashes bounds how many blocks a BlockStatus call may ask about
+	maxStatusHashes = 256
+
+	defaultResumeWindow = 5 * time.Minute
+)
+
+// Backend supplies block templates and accepts solved blocks on behalf of the pool.
+type Backend interface {
+	// Templates streams templates for request until ctx is done.
+	Templates(ctx context.Context, request *pb.CandidateRequest) (<-chan *pb.CandidateBlock, error)
+	Submit(ctx context.Context, block *pb.CandidateBlock, nonce uint32) (*pb.AckBlockSubmited, error)
+	Generate(ctx context.Context, numBlocks int) ([]string, error)
+}
+
+// StatusBackend is implemented by backends that can tell where submitted
+// blocks stand in the chain. Pools on such a backend offer FEATURE_BLOCK_STATUS.
+type StatusBackend interface {
+	BlockStatus(ctx context.Context, hashes []string) ([]*pb.BlockInfo, error)
+}
+
+type Server struct {
+	pb.UnimplementedCandidateStreamServer
+	pb.UnimplementedHealthServer
+
+	ProtocolVersion    uint32
+	MinProtocolVersion uint32
+	Algorithms         []string
+	Features           []pb.Feature
+
+	// Compressors the pool accepts by order of preference. Replies use the
+	// compressor of the request, so only the choice is made here.
+	Compressors []string
+
+	// ResumeWindow is how long a dropped Session can be resumed.
+	ResumeWindow time.Duration
+
+	backend Backend
+	status  atomic.Int32
+
+	peersMu sync.Mutex
+	peers   map[*peer]struct{}
+
+	workers *workerBook
+	resume  *resumeBook
+}
+
+// peer is a miner connected through a Session.
+type peer struct {
+	mu     sync.Mutex
+	stream pb.CandidateStream_SessionServer
+	worker string
+	jobs   *templateCache
+}
+
+func (p *peer) send(msg *pb.PoolMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stream.Send(msg)
+}
+
+func NewServer(backend Backend) *Server {
+	s := &Server{
+		ProtocolVersion:    ProtocolVersion,
+		MinProtocolVersion: MinProtocolVersion,
+		Algorithms:         []string{"scrypt"},
+		Features:           []pb.Feature{pb.Feature_FEATURE_SESSION, pb.Feature_FEATURE_COMPRESSION, pb.Feature_FEATURE_COINBASE_TEXT},
+		Compressors:        compress.Supported,
+		ResumeWindow:       defaultResumeWindow,
+		backend:            backend,
+		peers:              make(map[*peer]struct{}),
+		workers:            newWorkerBook(),
+		resume:             newResumeBook(),
+	}
+	if _, ok := backend.(StatusBackend); ok {
+		s.Features = append(s.Features, pb.Feature_FEATURE_BLOCK_STATUS)
+	}
+	s.status.Store(int32(pb.HealthStatus_SERVING))
+	return s
+}
+
+// Register exposes the pool and health services on registrar.
+func (s *Server) Register(registrar grpc.ServiceRegistrar) {
+	pb.RegisterCandidateStreamServer(registrar, s)
+	pb.RegisterHealthServer(registrar, s)
+}
+
+// ServeLocal serves the pool on a loopback port, so that an in-process miner
+// can reach it like any remote pool. It returns the address and a stop function.
+func (s *Server) ServeLocal() (string, func(), error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, err
+	}
+
+	srv := grpc.NewServer()
+	s.Register(srv)
+	go srv.Serve(lis)
+
+	return lis.Addr().String(), srv.Stop, nil
+}
+
+func (s *Server) SetStatus(status pb.HealthStatus) {
+	s.status.Store(int32(status))
+}
+
+// Notify shows message in the log of every miner connected through a Session.
+func (s *Server) Notify(message string) {
+	s.broadcast(&pb.PoolMessage{Payload: &pb.PoolMessage_Notice{Notice: &pb.Notice{Message: message}}})
+}
+
+// Maintenance reports the pool as under maintenance and warns connected
+// miners. A zero until means the end is unknown.
+func (s *Server) Maintenance(message string, until time.Time) {
+	s.SetStatus(pb.HealthStatus_MAINTENANCE)
+
+	maintenance := &pb.Maintenance{Message: message}
+	if !until.IsZero() {
+		maintenance.Until = until.Unix()
+	}
+	s.broadcast(&pb.PoolMessage{Payload: &pb.PoolMessage_Maintenance{Maintenance: maintenance}})
+}
+
+// Redirect asks connected miners to reconnect to address after delay.
+func (s *Server) Redirect(address string, delay time.Duration) {
+	reconnect := &pb.Reconnect{Address: address, DelaySeconds: uint32(delay.Seconds())}
+	s.broadcast(&pb.PoolMessage{Payload: &pb.PoolMessage_Reconnect{Reconnect: reconnect}})
+}
+
+func (s *Server) broadcast(msg *pb.PoolMessage) {
+	s.peersMu.Lock()
+	peers := make([]*peer, 0, len(s.peers))
+	for p := range s.peers {
+		peers = append(peers, p)
+	}
+	s.peersMu.Unlock()
+
+	for _, p := range peers {
+		if err := p.send(msg); err != nil {
+			log.Debug().Err(err).Msg("failed to push message to miner")
+		}
+	}
+}
+
+// Workers returns the activity of every worker seen since the server started.
+func (s *Server) Workers() []WorkerStats {
+	return s.workers.list()
+}
+
+// Peers returns the number of miners connected through a Session.
+func (s *Server) Peers() int {
+	s.peersMu.Lock()
+	defer s.peersMu.Unlock()
+	return len(s.peers)
+}
+
+func (s *Server) Check(ctx context.Context, in *pb.HealthCheckRequest) (*pb.HealthCheckResponse, error) {
+	return &pb.HealthCheckResponse{Status: pb.HealthStatus(s.status.Load())}, nil
+}
+
+func (s *Server) Hello(ctx context.Context, in *pb.HelloRequest) (*pb.HelloResponse, error) {
+	log.Info().Msgf("hello from miner %s speaking protocol v%d", in.SoftwareVersion, in.ProtocolVersion)
+
+	var features []pb.Feature
+	for _, f := range in.Features {
+		if slices.Contains(s.Features, f) {
+			features = append(features, f)
+		}
+	}
+
+	var compressor string
+	if slices.Contains(features, pb.Feature_FEATURE_COMPRESSION) {
+		for _, name := range s.Compressors {
+			if slices.Contains(in.Compressors, name) {
+				compressor = name
+				break
+			}
+		}
+	}
+
+	return &pb.HelloResponse{
+		ProtocolVersion:    s.ProtocolVersion,
+		MinProtocolVersion: s.MinProtocolVersion,
+		SoftwareVersion:    utils.Version,
+		Algorithms:         s.Algorithms,
+		Features:           features,
+		Compressor:         compressor,
+	}, nil
+}
+
+// Open streams templates to a miner without a session. Its jobs are kept by
+// connection for ResumeWindow after the stream ends, for the SubmitCompact
+// calls made on that connection.
+func (s *Server) Open(in *pb.CandidateRequest, stream pb.CandidateStream_OpenServer) error {
+	worker := s.workers.connect(in.Worker)
+	defer s.workers.disconnect(worker)
+	log.Info().Msgf("worker %s connected", worker)
+
+	p := &peer{worker: worker}
+	state := s.resume.attachConn(conn(stream.Context()), p, time.Now())
+	defer func() { s.resume.detach(state, p, s.ResumeWindow, time.Now()) }()
+
+	templates, err := s.backend.Templates(stream.Context(), in)
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "no templates available: %v", err)
+	}
+
+	for {
+		select {
+		case block, ok := <-templates:
+			if !ok {
+				return status.Error(codes.Unavailable, "template source closed")
+			}
+			state.jobs.Put(block)
+			if err := stream.Send(block); err != nil {
+				return err
+			}
+
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func (s *Server) SubmitValidBlock(ctx context.Context, in *pb.ValidBlock) (*pb.AckBlockSubmited, error) {
+	if in.GetTemplate() == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing template")
+	}
+	if in.Nonce < 0 || in.Nonce > int64(^uint32(0)) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid nonce %d", in.Nonce)
+	}
+	return s.backend.Submit(ctx, in.Template, uint32(in.Nonce))
+}
+
+func (s *Server) SubmitCompact(ctx context.Context, in *pb.CompactSolution) (*pb.AckBlockSubmited, error) {
+	state := s.resume.lookupConn(conn(ctx))
+	if state == nil {
+		return nil, status.Errorf(codes.NotFound, "unknown or expired job %s", in.JobId)
+	}
+	return s.submitCompact(ctx, state.jobs, in)
+}
+
+// conn identifies the connection a call arrives on.
+func conn(ctx context.Context) string {
+	if p, ok := grpcpeer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// submitCompact resolves the job of a compact solution among jobs.
+func (s *Server) submitCompact(ctx context.Context, jobs *templateCache, in *pb.CompactSolution) (*pb.AckBlockSubmited, error) {
+	template := jobs.Get(in.JobId)
+	if template == nil {
+		return nil, status.Errorf(codes.NotFound, "unknown or expired job %s", in.JobId)
+	}
+	if len(in.Extranonce) > 0 {
+		return nil, status.Error(codes.Unimplemented, "extranonce rolling is not supported by this pool")
+	}
+	if in.Ntime != 0 {
+		return nil, status.Error(codes.Unimplemented, "ntime rolling is not supported by this pool")
+	}
+	return s.backend.Submit(ctx, template, in.Nonce)
+}
+
+// Session serves jobs, solutions and keepalives over a single stream. The
+// miner opens it with a request and may send another one later, the next
+// jobs being built from it; every solution is answered with an ack carrying
+// the same id. Sessions are resumable: jobs handed out on a dropped
+// session stay valid on the next one opened with its token.
+func (s *Server) Session(stream pb.CandidateStream_SessionServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	request := first.GetRequest()
+	if request == nil {
+		return status.Error(codes.InvalidArgument, "session must start with a candidate request")
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	// renewed requests replace the template stream of the previous one
+	stopTemplates := context.CancelFunc(func() {})
+	defer func() { stopTemplates() }()
+	openTemplates := func(request *pb.CandidateRequest) (<-chan *pb.CandidateBlock, error) {
+		stopTemplates()
+		var templatesCtx context.Context
+		templatesCtx, stopTemplates = context.WithCancel(ctx)
+		return s.backend.Templates(templatesCtx, request)
+	}
+
+	templates, err := openTemplates(request)
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "no templates available: %v", err)
+	}
+
+	p := &peer{stream: stream, worker: s.workers.connect(request.Worker)}
+	defer s.workers.disconnect(p.worker)
+
+	state, resumed := s.resume.attach(request.ResumeToken, p, time.Now())
+	defer func() { s.resume.detach(state, p, s.ResumeWindow, time.Now()) }()
+	p.jobs = state.jobs
+
+	if resumed {
+		log.Info().Msgf("worker %s resumed its session", p.worker)
+	} else {
+		log.Info().Msgf("worker %s opened a session", p.worker)
+	}
+	if err := p.send(&pb.PoolMessage{Payload: &pb.PoolMessage_Resume{Resume: &pb.Resume{Token: state.token, Resumed: resumed}}}); err != nil {
+		return err
+	}
+
+	s.peersMu.Lock()
+	s.peers[p] = struct{}{}
+	s.peersMu.Unlock()
+
+	defer func() {
+		s.peersMu.Lock()
+		delete(s.peers, p)
+		s.peersMu.Unlock()
+	}()
+
+	renewals := make(chan *pb.CandidateRequest)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.serveSession(ctx, p, renewals)
+	}()
+
+	for {
+		select {
+		case block, ok := <-templates:
+			if !ok {
+				return status.Error(codes.Unavailable, "template source closed")
+			}
+			p.jobs.Put(block)
+			if err := p.send(&pb.PoolMessage{Payload: &pb.PoolMessage_Job{Job: block}}); err != nil {
+				return err
+			}
+
+		case request := <-renewals:
+			if templates, err = openTemplates(request); err != nil {
+				return status.Errorf(codes.Unavailable, "no templates available: %v", err)
+			}
+			log.Info().Msgf("worker %s renewed its candidate request", p.worker)
+
+		case err := <-errc:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// serveSession handles the messages sent by the miner until the stream ends,
+// passing renewed requests on to Session.
+func (s *Server) serveSession(ctx context.Context, p *peer, renewals chan<- *pb.CandidateRequest) error {
+	for {
+		msg, err := p.stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		var reply *pb.PoolMessage
+		switch payload := msg.Payload.(type) {
+		case *pb.MinerMessage_Solution:
+			ack := s.ack(ctx, p.jobs, payload.Solution)
+			s.workers.update(p.worker, func(stats *WorkerStats) {
+				if ack.Accepted {
+					stats.Accepted++
+				} else {
+					stats.Rejected++
+				}
+			})
+			reply = &pb.PoolMessage{Payload: &pb.PoolMessage_Ack{Ack: ack}}
+
+		case *pb.MinerMessage_Keepalive:
+			if payload.Keepalive.Reply {
+				continue
+			}
+			reply = &pb.PoolMessage{Payload: &pb.PoolMessage_Keepalive{Keepalive: &pb.Keepalive{Timestamp: payload.Keepalive.Timestamp, Reply: true}}}
+
+		case *pb.MinerMessage_Request:
+			select {
+			case renewals <- payload.Request:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+
+		if reply != nil {
+			if err := p.send(reply); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Server) ack(ctx context.Context, jobs *templateCache, solution *pb.Solution) *pb.Ack {
+	var (
+		resp *pb.AckBlockSubmited
+		err  error
+	)
+	switch payload := solution.Payload.(type) {
+	case *pb.Solution_Compact:
+		resp, err = s.submitCompact(ctx, jobs, payload.Compact)
+	default:
+		resp, err = s.SubmitValidBlock(ctx, solution.GetBlock())
+	}
+	if err != nil {
+		st := status.Convert(err)
+		return &pb.Ack{Id: solution.Id, Code: uint32(st.Code()), Reason: st.Message()}
+	}
+	return &pb.Ack{Id: solution.Id, Accepted: true, Header: resp.Header}
+}
+
+func (s *Server) Generate(ctx context.Context, in *pb.GenerateRequest) (*pb.GenerateResponse, error) {
+	blocks, err := s.backend.Generate(ctx, int(in.NumBlocks))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GenerateResponse{Blocks: blocks}, nil
+}
+
+func (s *Server) BlockStatus(ctx context.Context, in *pb.BlockStatusRequest) (*pb.BlockStatusResponse, error) {
+	backend, ok := s.backend.(StatusBackend)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "the pool does not track blocks")
+	}
+	if len(in.Hashes) > maxStatusHashes {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d blocks per call, got %d", maxStatusHashes, len(in.Hashes))
+	}
+	for _, hash := range in.Hashes {
+		if len(hash) != 64 {
+			return nil, status.Errorf(codes.InvalidArgument, "malformed block hash %q", hash)
+		}
+		if _, err := hex.DecodeString(hash); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "malformed block hash %q", hash)
+		}
+	}
+
+	blocks, err := backend.BlockStatus(ctx, in.Hashes)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.BlockStatusResponse{Blocks: blocks}, nil
+}
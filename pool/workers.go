@@ -0,0 +1,90 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package pool
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flokiorg/grpc-miner/mining/pb"
+)
+
+// WorkerStats is the activity of a worker as seen by the pool. Solutions are
+// attributed to the worker of the Session they were sent on.
+type WorkerStats struct {
+	Name            string
+	RigID           string
+	SoftwareVersion string
+	Algorithm       string
+	Threads         uint32
+
+	Connections int
+	Accepted    uint64
+	Rejected    uint64
+	LastSeen    time.Time
+}
+
+type workerBook struct {
+	mu      sync.Mutex
+	workers map[string]*WorkerStats
+}
+
+func newWorkerBook() *workerBook {
+	return &workerBook{workers: make(map[string]*WorkerStats)}
+}
+
+// connect registers a stream opened by worker and returns its key. Miners
+// predating worker identity are grouped under an empty name.
+func (b *workerBook) connect(worker *pb.Worker) string {
+	key := worker.GetName() + "/" + worker.GetRigId()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats, ok := b.workers[key]
+	if !ok {
+		stats = &WorkerStats{Name: worker.GetName(), RigID: worker.GetRigId()}
+		b.workers[key] = stats
+	}
+	stats.SoftwareVersion = worker.GetSoftwareVersion()
+	stats.Algorithm = worker.GetAlgorithm()
+	stats.Threads = worker.GetThreads()
+	stats.Connections++
+	stats.LastSeen = time.Now()
+	return key
+}
+
+func (b *workerBook) disconnect(key string) {
+	b.update(key, func(stats *WorkerStats) { stats.Connections-- })
+}
+
+func (b *workerBook) update(key string, fn func(*WorkerStats)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if stats, ok := b.workers[key]; ok {
+		fn(stats)
+		stats.LastSeen = time.Now()
+	}
+}
+
+func (b *workerBook) list() []WorkerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	list := make([]WorkerStats, 0, len(b.workers))
+	for _, stats := range b.workers {
+		list = append(list, *stats)
+	}
+	slices.SortFunc(list, func(a, b WorkerStats) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return strings.Compare(a.RigID, b.RigID)
+	})
+	return list
+}
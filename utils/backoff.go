@@ -0,0 +1,76 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package utils
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff spaces out retries. The ceiling doubles with every attempt, from
+// twice Min up to Max, and each delay is drawn uniformly between Min and the
+// ceiling so that clients failing together do not retry in lock-step.
+type Backoff struct {
+	Min   time.Duration
+	Max   time.Duration
+	Clock Clock
+
+	// Rand returns a number in [0, n), it is replaceable for tests.
+	Rand func(n int64) int64
+
+	attempt int
+}
+
+func NewBackoff(min, max time.Duration, clock Clock) *Backoff {
+	if clock == nil {
+		clock = RealClock
+	}
+	if max < min {
+		max = min
+	}
+	return &Backoff{
+		Min:   min,
+		Max:   max,
+		Clock: clock,
+		Rand:  rand.Int64N,
+	}
+}
+
+// Next returns the delay before the next attempt.
+func (b *Backoff) Next() time.Duration {
+	ceiling := b.Max
+	if b.attempt < 62 && b.Min<<(b.attempt+1) > 0 {
+		ceiling = min(b.Max, b.Min<<(b.attempt+1))
+	}
+	b.attempt++
+
+	if ceiling <= b.Min {
+		return b.Min
+	}
+	return b.Min + time.Duration(b.Rand(int64(ceiling-b.Min)+1))
+}
+
+// Sleep waits for d, usually a delay returned by Next. It returns early
+// without error when wake fires, and with the context error when ctx is done.
+func (b *Backoff) Sleep(ctx context.Context, d time.Duration, wake <-chan struct{}) error {
+	select {
+	case <-b.Clock.After(d):
+		return nil
+	case <-wake:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Attempt returns how many delays were handed out since the last reset.
+func (b *Backoff) Attempt() int {
+	return b.attempt
+}
+
+func (b *Backoff) Reset() {
+	b.attempt = 0
+}
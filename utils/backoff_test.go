@@ -0,0 +1,85 @@
+// Copyright (c) 2024 The Flokicoin developers
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php.
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackoffBounds(t *testing.T) {
+	tests := []struct {
+		name string
+		rand func(n int64) int64
+		want []time.Duration
+	}{
+		{"lowest draw", func(n int64) int64 { return 0 }, []time.Duration{1, 1, 1, 1, 1, 1}},
+		{"highest draw", func(n int64) int64 { return n - 1 }, []time.Duration{2, 4, 8, 16, 30, 30}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := NewBackoff(time.Second, 30*time.Second, nil)
+			b.Rand = test.rand
+
+			for i, want := range test.want {
+				if got := b.Next(); got != want*time.Second {
+					t.Fatalf("attempt %d: want=%s got=%s", i, want*time.Second, got)
+				}
+			}
+
+			b.Reset()
+			if got := b.Next(); got > 2*time.Second {
+				t.Fatalf("backoff not reset, got=%s", got)
+			}
+		})
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	b := NewBackoff(time.Second, time.Minute, nil)
+
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 20; i++ {
+		b.Reset()
+		b.Next()
+		b.Next()
+		seen[b.Next()] = true
+	}
+	if len(seen) < 2 {
+		t.Fatal("expected delays to be spread out")
+	}
+}
+
+func TestBackoffSleep(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	b := NewBackoff(time.Second, time.Second, clock)
+
+	done := make(chan error, 1)
+	go func() { done <- b.Sleep(context.Background(), b.Next(), nil) }()
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wake := make(chan struct{})
+	go func() { done <- b.Sleep(context.Background(), b.Next(), wake) }()
+	clock.BlockUntil(1)
+	close(wake)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error on wake: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { done <- b.Sleep(ctx, b.Next(), nil) }()
+	clock.BlockUntil(2)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got=%v", err)
+	}
+}
@@ -5,8 +5,11 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 )
@@ -37,6 +40,22 @@ func FileExists(path string) bool {
 	return err == nil || !os.IsNotExist(err)
 }
 
+// RigID derives a stable identifier for this machine from its machine id,
+// without disclosing the id itself. It is empty when no machine id is found.
+func RigID() string {
+	for _, path := range []string{"/etc/machine-id", "/var/lib/dbus/machine-id"} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if id := strings.TrimSpace(string(data)); id != "" {
+			sum := sha256.Sum256([]byte(id))
+			return hex.EncodeToString(sum[:6])
+		}
+	}
+	return ""
+}
+
 func GetFullPath(filename string) (string, error) {
 	dir, err := os.Getwd() // Get current working directory
 	if err != nil {
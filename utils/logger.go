@@ -15,7 +15,9 @@ var (
 	FileLogger    zerolog.Logger
 )
 
-func CreateFileLogger(logpath string) zerolog.Logger {
+// CreateFileLogger returns a logger writing to the console and to logpath,
+// along with the log file so it can be synced and closed on exit.
+func CreateFileLogger(logpath string) (zerolog.Logger, *os.File) {
 	consoleWriter := zerolog.ConsoleWriter{
 		Out: os.Stdout,
 	}
@@ -32,6 +34,6 @@ func CreateFileLogger(logpath string) zerolog.Logger {
 		zerolog.ConsoleWriter{Out: logFile, TimeFormat: "2006-01-02 15:04:05", NoColor: true},
 	)
 
-	return zerolog.New(multiWriter).With().Timestamp().Logger().Level(zerolog.InfoLevel)
+	return zerolog.New(multiWriter).With().Timestamp().Logger().Level(zerolog.InfoLevel), logFile
 
 }